@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sethvargo/go-githubactions"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const actDefaultEndpoint = "localhost:4317"
+
+// actRuntimeToken is the placeholder ACTIONS_RUNTIME_TOKEN act sets when it
+// runs an artifact server.
+const actRuntimeToken = "token"
+
+// isAct reports whether the action runs under nektos/act. ACT can leak into
+// any runner through a workflow env block, so it is only trusted without the
+// RUNNER_ENVIRONMENT that hosted and self-hosted runners set, and without a
+// real ACTIONS_RUNTIME_TOKEN.
+func isAct() bool {
+	if os.Getenv("ACT") != "true" {
+		return false
+	}
+	if _, ok := os.LookupEnv("RUNNER_ENVIRONMENT"); ok {
+		return false
+	}
+	token := os.Getenv("ACTIONS_RUNTIME_TOKEN")
+	return token == "" || token == actRuntimeToken
+}
+
+// applyActDefaults exports to the collector act users typically run locally
+// when no endpoint is configured. Only that default endpoint connects without
+// TLS, so a configured endpoint, which may be a remote collector, keeps its TLS
+// settings.
+func applyActDefaults(params *InputParams) {
+	insecure := !insecureSet()
+	for i := range params.Exporters {
+		exporter := &params.Exporters[i]
+		if exporter.Endpoint == "" {
+			exporter.Endpoint = actDefaultEndpoint
+			if insecure {
+				exporter.Insecure = true
+			}
+		}
+		githubactions.Infof("Detected act, exporting to %s (insecure: %t)", exporter.Endpoint, exporter.Insecure)
+	}
+	params.LogSpan = true
+}
+
+func logSpan(name string, spanContext trace.SpanContext, attributes []attribute.KeyValue) {
+	githubactions.Infof("Span %q trace_id=%s span_id=%s", name, spanContext.TraceID(), spanContext.SpanID())
+	for _, kv := range attributes {
+		githubactions.Infof("  %s=%s", kv.Key, kv.Value.Emit())
+	}
+}
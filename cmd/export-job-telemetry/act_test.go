@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+)
+
+func TestApplyActDefaults(t *testing.T) {
+	tests := []struct {
+		name         string
+		insecure     string
+		endpoint     string
+		wantEndpoint string
+		wantInsecure bool
+	}{
+		{name: "default endpoint", wantEndpoint: actDefaultEndpoint, wantInsecure: true},
+		{name: "default endpoint with explicit insecure", insecure: "false", wantEndpoint: actDefaultEndpoint},
+		{name: "configured endpoint", endpoint: "collector.example.com:443", wantEndpoint: "collector.example.com:443"},
+		{name: "configured endpoint with explicit insecure", insecure: "true", endpoint: "collector:4317", wantEndpoint: "collector:4317", wantInsecure: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("INPUT_OTEL-EXPORTER-OTLP-INSECURE", tt.insecure)
+			t.Setenv("OTEL_EXPORTER_OTLP_TRACES_INSECURE", "")
+			t.Setenv("OTEL_EXPORTER_OTLP_INSECURE", "")
+
+			params := InputParams{Exporters: []telemetry.ExporterConfig{{
+				Endpoint: tt.endpoint,
+				Insecure: tt.insecure == "true",
+			}}}
+			applyActDefaults(&params)
+
+			exporter := params.Exporters[0]
+			if exporter.Endpoint != tt.wantEndpoint || exporter.Insecure != tt.wantInsecure {
+				t.Errorf("applyActDefaults() = %q, insecure %t, want %q, insecure %t", exporter.Endpoint, exporter.Insecure, tt.wantEndpoint, tt.wantInsecure)
+			}
+			if !params.LogSpan {
+				t.Error("applyActDefaults() did not enable span logging")
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func addJobAnnotations(ctx context.Context, client *githubClient, owner, repo string, span trace.Span, job workflowJob, timestamp time.Time) error {
+	annotations, err := client.listJobAnnotations(ctx, owner, repo, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list annotations for job %d: %w", job.ID, err)
+	}
+
+	converted := make([]telemetry.Annotation, 0, len(annotations))
+	for _, annotation := range annotations {
+		converted = append(converted, telemetry.Annotation{
+			Level:     annotation.AnnotationLevel,
+			Title:     annotation.Title,
+			Message:   annotation.Message,
+			Path:      annotation.Path,
+			StartLine: annotation.StartLine,
+			EndLine:   annotation.EndLine,
+		})
+	}
+	telemetry.AddAnnotationEvents(span, converted, timestamp)
+	return nil
+}
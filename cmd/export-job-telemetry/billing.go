@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sethvargo/go-githubactions"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// addJobBilling sets the billable time of job, taken from the timing of its
+// run, on span and, when rates has a per-minute rate for its runner OS, its
+// cost. GitHub bills each job rounded up to the whole minute.
+func addJobBilling(span trace.Span, job workflowJob, timing workflowRunTiming, rates map[string]float64) {
+	for runnerOS, billable := range timing.Billable {
+		for _, jobRun := range billable.JobRuns {
+			if jobRun.JobID != job.ID {
+				continue
+			}
+			span.SetAttributes(
+				attribute.Int64("ci.github.workflow.job.billable_ms", jobRun.DurationMS),
+				attribute.String("ci.github.workflow.job.billable_os", runnerOS),
+			)
+			if rate, ok := rates[strings.ToUpper(runnerOS)]; ok {
+				minutes := math.Ceil(float64(jobRun.DurationMS) / 60000)
+				span.SetAttributes(attribute.Float64("ci.github.workflow.job.cost_usd", minutes*rate))
+			}
+			return
+		}
+	}
+	githubactions.Debugf("No billable time reported for job %d", job.ID)
+}
+
+// runnerCostPerMinute parses the runner-cost-per-minute input, a map of runner
+// OS to USD per minute such as UBUNTU=0.008,MACOS=0.08.
+func runnerCostPerMinute() (map[string]float64, error) {
+	pairs := parseKeyValuePairs("runner-cost-per-minute", githubactions.GetInput("runner-cost-per-minute"))
+	rates := make(map[string]float64, len(pairs))
+	for runnerOS, value := range pairs {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid runner-cost-per-minute: %s=%q", runnerOS, value)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(runnerOS))] = rate
+	}
+	return rates, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// testBillingRunJobs is the response of the jobs of run 1 of octo/repo, two
+// completed jobs billed on Ubuntu.
+const testBillingRunJobs = `{"total_count": 2, "jobs": [
+	{"id": 7, "run_id": 1, "run_attempt": 1, "name": "build", "status": "completed", "conclusion": "success",
+	 "started_at": "2024-01-01T00:00:00Z", "completed_at": "2024-01-01T00:01:00Z"},
+	{"id": 8, "run_id": 1, "run_attempt": 1, "name": "test", "status": "completed", "conclusion": "success",
+	 "started_at": "2024-01-01T00:00:00Z", "completed_at": "2024-01-01T00:01:30Z"}
+]}`
+
+const testBillingRunTiming = `{"billable": {"UBUNTU": {"total_ms": 150000, "jobs": 2, "job_runs": [
+	{"job_id": 7, "duration_ms": 60000},
+	{"job_id": 8, "duration_ms": 90000}
+]}}, "run_duration_ms": 90000}`
+
+func TestWorkflowRunBilling(t *testing.T) {
+	var timingRequests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/actions/runs/1":
+			fmt.Fprint(w, `{"id": 1, "name": "CI", "run_attempt": 1, "conclusion": "success", "run_started_at": "2024-01-01T00:00:00Z"}`)
+		case "/repos/octo/repo/actions/runs/1/attempts/1/jobs":
+			fmt.Fprint(w, testBillingRunJobs)
+		case "/repos/octo/repo/actions/runs/1/timing":
+			timingRequests.Add(1)
+			fmt.Fprint(w, testBillingRunTiming)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, endpoint := startCollector(t)
+	setInputs(t, endpoint, map[string]string{
+		"mode":                   modeWorkflowRun,
+		"run-id":                 "1",
+		"github-token":           "token",
+		"github-api-url":         server.URL,
+		"export-billable-time":   "true",
+		"runner-cost-per-minute": "UBUNTU=0.008",
+	})
+	t.Setenv("GITHUB_REPOSITORY", "octo/repo")
+
+	if err := run(false); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+
+	if n := timingRequests.Load(); n != 1 {
+		t.Errorf("requested the run timing %d times, want once per run", n)
+	}
+	for job, want := range map[string]struct {
+		billableMS int64
+		costUSD    float64
+	}{
+		"build": {billableMS: 60000, costUSD: 0.008},
+		"test":  {billableMS: 90000, costUSD: 0.016},
+	} {
+		span := findSpan(c.Spans(), job)
+		if span == nil {
+			t.Errorf("exported no %q span", job)
+			continue
+		}
+		var billableMS int64
+		var costUSD float64
+		for _, kv := range span.GetAttributes() {
+			switch kv.GetKey() {
+			case "ci.github.workflow.job.billable_ms":
+				billableMS = kv.GetValue().GetIntValue()
+			case "ci.github.workflow.job.cost_usd":
+				costUSD = kv.GetValue().GetDoubleValue()
+			}
+		}
+		if billableMS != want.billableMS || costUSD != want.costUSD {
+			t.Errorf("%q billable_ms, cost_usd = %d, %v, want %d, %v", job, billableMS, costUSD, want.billableMS, want.costUSD)
+		}
+	}
+}
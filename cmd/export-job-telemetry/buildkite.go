@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// buildkiteProvider reads the job from the BUILDKITE_* environment of the
+// Buildkite agent. Buildkite does not expose the start time of a job, so
+// started-at must be recorded, e.g. in a pre-command hook.
+type buildkiteProvider struct{}
+
+func (buildkiteProvider) Name() string {
+	return providerBuildkite
+}
+
+// ApplyDefaults derives the job status from BUILDKITE_COMMAND_EXIT_STATUS,
+// which is set in post-command hooks.
+func (buildkiteProvider) ApplyDefaults(params *InputParams) {
+	if params.JobStatus == "" {
+		if status, err := strconv.Atoi(os.Getenv("BUILDKITE_COMMAND_EXIT_STATUS")); err == nil {
+			params.JobStatus = telemetry.ConclusionSuccess
+			if status != 0 {
+				params.JobStatus = telemetry.ConclusionFailure
+			}
+		}
+	}
+	if params.JobName == "" {
+		params.JobName = os.Getenv("BUILDKITE_LABEL")
+	}
+}
+
+func (buildkiteProvider) SpanNameValues(params InputParams) map[string]string {
+	job := params.JobName
+	if job == "" {
+		job = os.Getenv("BUILDKITE_LABEL")
+	}
+	return map[string]string{
+		"workflow":    os.Getenv("BUILDKITE_PIPELINE_NAME"),
+		"job":         job,
+		"repository":  buildkitePipeline(),
+		"ref":         buildkiteRef(),
+		"sha":         os.Getenv("BUILDKITE_COMMIT"),
+		"actor":       os.Getenv("BUILDKITE_BUILD_CREATOR"),
+		"event":       os.Getenv("BUILDKITE_SOURCE"),
+		"run_id":      os.Getenv("BUILDKITE_BUILD_ID"),
+		"run_number":  os.Getenv("BUILDKITE_BUILD_NUMBER"),
+		"run_attempt": strconv.FormatInt(buildkiteAttempt(), 10),
+		"runner_os":   runtime.GOOS,
+		"status":      params.JobStatus,
+	}
+}
+
+func (buildkiteProvider) Attributes() []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+	addString := func(key, value string) {
+		if value != "" {
+			attributes = append(attributes, attribute.String(key, value))
+		}
+	}
+
+	addString("ci.github.workflow.name", os.Getenv("BUILDKITE_PIPELINE_NAME"))
+	addString("ci.github.workflow.run.id", os.Getenv("BUILDKITE_BUILD_ID"))
+	if number, err := strconv.ParseInt(os.Getenv("BUILDKITE_BUILD_NUMBER"), 10, 64); err == nil {
+		attributes = append(attributes, attribute.Int64("ci.github.workflow.run.number", number))
+	}
+	attributes = append(attributes, attribute.Int64("ci.github.workflow.run.attempt", buildkiteAttempt()))
+	addString("ci.github.workflow.job.name", os.Getenv("BUILDKITE_LABEL"))
+	addString("ci.github.workflow.job.id", os.Getenv("BUILDKITE_JOB_ID"))
+	addString("ci.github.repository", buildkitePipeline())
+	addString("ci.github.repository.url", os.Getenv("BUILDKITE_REPO"))
+	addString("ci.github.ref.name", buildkiteRef())
+	if os.Getenv("BUILDKITE_TAG") != "" {
+		addString("ci.github.ref.type", "tag")
+	} else if os.Getenv("BUILDKITE_BRANCH") != "" {
+		addString("ci.github.ref.type", "branch")
+	}
+	addString("ci.github.sha", os.Getenv("BUILDKITE_COMMIT"))
+	if number, err := strconv.ParseInt(os.Getenv("BUILDKITE_PULL_REQUEST"), 10, 64); err == nil {
+		attributes = append(attributes, attribute.Int64("ci.github.pull_request.number", number))
+	}
+	addString("ci.buildkite.build.url", os.Getenv("BUILDKITE_BUILD_URL"))
+	addString("ci.buildkite.build.creator", os.Getenv("BUILDKITE_BUILD_CREATOR"))
+	addString("ci.buildkite.source", os.Getenv("BUILDKITE_SOURCE"))
+	addString("ci.buildkite.step.key", os.Getenv("BUILDKITE_STEP_KEY"))
+	addString("ci.buildkite.agent.name", os.Getenv("BUILDKITE_AGENT_NAME"))
+	return attributes
+}
+
+func (buildkiteProvider) RunAttributes() []attribute.KeyValue {
+	return nil
+}
+
+func (buildkiteProvider) TraceID() (trace.TraceID, bool) {
+	buildID := os.Getenv("BUILDKITE_BUILD_ID")
+	if buildID == "" {
+		return trace.TraceID{}, false
+	}
+	return telemetry.PipelineTraceID(providerBuildkite, buildID), true
+}
+
+func (buildkiteProvider) JobSpanID(jobName string) (trace.SpanID, bool) {
+	number, err := strconv.ParseInt(os.Getenv("BUILDKITE_BUILD_NUMBER"), 10, 64)
+	if err != nil {
+		return trace.SpanID{}, false
+	}
+	return telemetry.JobSpanID(buildkitePipeline(), number, buildkiteAttempt(), jobName), true
+}
+
+// buildkitePipeline identifies the pipeline as organization/pipeline.
+func buildkitePipeline() string {
+	org, pipeline := os.Getenv("BUILDKITE_ORGANIZATION_SLUG"), os.Getenv("BUILDKITE_PIPELINE_SLUG")
+	if org == "" || pipeline == "" {
+		return pipeline
+	}
+	return org + "/" + pipeline
+}
+
+func buildkiteRef() string {
+	if tag := os.Getenv("BUILDKITE_TAG"); tag != "" {
+		return tag
+	}
+	return os.Getenv("BUILDKITE_BRANCH")
+}
+
+// buildkiteAttempt counts the attempts of the job, starting at 1.
+func buildkiteAttempt() int64 {
+	retries, _ := strconv.ParseInt(os.Getenv("BUILDKITE_RETRY_COUNT"), 10, 64)
+	return retries + 1
+}
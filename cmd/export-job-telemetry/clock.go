@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+// clockSkewThreshold is the smallest skew that is corrected. The Date header
+// only has a resolution of a second, so smaller offsets are noise.
+const clockSkewThreshold = 2 * time.Second
+
+// measureClockSkew returns how far the runner clock is behind the GitHub clock,
+// which started-at and created-at come from; it is negative when the runner
+// clock is ahead. It reports false when the skew cannot be measured or is below
+// clockSkewThreshold.
+func measureClockSkew(ctx context.Context, params InputParams) (time.Duration, bool) {
+	ghctx, err := githubactions.Context()
+	if err != nil {
+		githubactions.Warningf("failed to read GitHub context: %v", err)
+		return 0, false
+	}
+
+	server, local, err := newGitHubClient(githubAPIURL(params, ghctx), params.GitHubToken).serverTime(ctx)
+	if err != nil {
+		githubactions.Warningf("failed to measure clock skew: %v", err)
+		return 0, false
+	}
+
+	skew := server.Sub(local).Round(time.Second)
+	if skew.Abs() < clockSkewThreshold {
+		return 0, false
+	}
+	return skew, true
+}
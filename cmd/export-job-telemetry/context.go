@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sethvargo/go-githubactions"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// githubContextAttributes describes the workflow run and runner the action runs
+// in, as exposed through the GITHUB_* and RUNNER_* environment.
+func githubContextAttributes() []attribute.KeyValue {
+	ghctx, err := githubactions.Context()
+	if err != nil {
+		githubactions.Warningf("failed to read GitHub context: %v", err)
+		return nil
+	}
+
+	var attributes []attribute.KeyValue
+	addString := func(key, value string) {
+		if value != "" {
+			attributes = append(attributes, attribute.String(key, value))
+		}
+	}
+	addInt := func(key string, value int64) {
+		if value != 0 {
+			attributes = append(attributes, attribute.Int64(key, value))
+		}
+	}
+
+	addString("ci.github.workflow.name", ghctx.Workflow)
+	addInt("ci.github.workflow.run.id", ghctx.RunID)
+	addInt("ci.github.workflow.run.attempt", ghctx.RunAttempt)
+	addInt("ci.github.workflow.run.number", ghctx.RunNumber)
+	addString("ci.github.workflow.job.name", ghctx.Job)
+	addString("ci.github.repository", ghctx.Repository)
+	if ghctx.Repository != "" {
+		addString("ci.github.repository.url", ghctx.ServerURL+"/"+ghctx.Repository)
+	}
+	addString("ci.github.ref.name", ghctx.RefName)
+	addString("ci.github.ref.type", ghctx.RefType)
+	addString("ci.github.sha", ghctx.SHA)
+	addString("ci.github.actor", ghctx.Actor)
+	addString("ci.github.event_name", ghctx.EventName)
+	addString("ci.github.runner.name", os.Getenv("RUNNER_NAME"))
+	addString("ci.github.runner.os", os.Getenv("RUNNER_OS"))
+	addString("ci.github.runner.arch", os.Getenv("RUNNER_ARCH"))
+	addString("ci.github.runner.environment", os.Getenv("RUNNER_ENVIRONMENT"))
+
+	return append(attributes, pullRequestAttributes(ghctx.Event)...)
+}
+
+// pullRequestAttributes describes the pull request of a pull_request or
+// pull_request_target event payload, so traces can be filtered by pull request.
+func pullRequestAttributes(event map[string]any) []attribute.KeyValue {
+	pr, ok := event["pull_request"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var attributes []attribute.KeyValue
+	addString := func(key string, value any) {
+		if s, ok := value.(string); ok && s != "" {
+			attributes = append(attributes, attribute.String(key, s))
+		}
+	}
+	field := func(object, key string) any {
+		if m, ok := pr[object].(map[string]any); ok {
+			return m[key]
+		}
+		return nil
+	}
+
+	if number, ok := pr["number"].(float64); ok {
+		attributes = append(attributes, attribute.Int64("ci.github.pull_request.number", int64(number)))
+	}
+	addString("ci.github.pull_request.title", pr["title"])
+	addString("ci.github.pull_request.url", pr["html_url"])
+	addString("ci.github.pull_request.author", field("user", "login"))
+	addString("ci.github.pull_request.head.ref", field("head", "ref"))
+	addString("ci.github.pull_request.head.sha", field("head", "sha"))
+	addString("ci.github.pull_request.base.ref", field("base", "ref"))
+	addString("ci.github.pull_request.base.sha", field("base", "sha"))
+	if draft, ok := pr["draft"].(bool); ok {
+		attributes = append(attributes, attribute.Bool("ci.github.pull_request.draft", draft))
+	}
+	return attributes
+}
+
+// runnerAttributes describes the runner a job ran on, so queue times can be
+// analysed per runner pool.
+func runnerAttributes(name string, labels []string) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+	if name != "" {
+		attributes = append(attributes, attribute.String("ci.github.workflow.job.runner.name", name))
+	}
+	if len(labels) > 0 {
+		attributes = append(attributes, attribute.StringSlice("ci.github.workflow.job.runner.labels", labels))
+	}
+	return attributes
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/status"
+)
+
+const redacted = "***"
+
+// debugEnabled reports whether the debug input is set, or the workflow is
+// re-run with debug logging, which sets RUNNER_DEBUG.
+func debugEnabled() (bool, error) {
+	debug, err := parseBoolInput("debug")
+	return debug || os.Getenv("RUNNER_DEBUG") == "1", err
+}
+
+// logConfiguration logs the resolved configuration, including values taken
+// from environment variables, with secrets redacted.
+func logConfiguration(params InputParams) {
+	githubactions.Group("Resolved configuration")
+	defer githubactions.EndGroup()
+
+	mode := params.Mode
+	if mode == "" {
+		mode = modeJob
+	}
+	githubactions.Infof("mode=%s phase=%s", mode, params.Phase)
+	githubactions.Infof("service.name=%q attribute-schema=%s", params.OtelServiceName, params.AttributeSchema)
+	if params.Sampler != nil {
+		githubactions.Infof("sampler=%s", params.Sampler.Description())
+	}
+	logAttributes("resource attribute", params.OtelResourceAttrs)
+	logAttributes("baseline attribute", params.BaselineAttrs)
+	for _, member := range params.Baggage.Members() {
+		githubactions.Infof("baggage %s=%q", member.Key(), member.Value())
+	}
+
+	for i, exporter := range params.Exporters {
+		githubactions.Infof("exporter[%d] type=%s endpoint=%q protocol=%s insecure=%t compression=%s timeout=%s",
+			i, exporter.Type, exporter.Endpoint, exporter.Protocol, exporter.Insecure, exporter.Compression, exporter.Timeout)
+		if exporter.Type == telemetry.ExporterTypeFile {
+			githubactions.Infof("exporter[%d] file=%s", i, exporter.FilePath)
+		}
+		keys := make([]string, 0, len(exporter.Headers))
+		for key := range exporter.Headers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			githubactions.Infof("exporter[%d] header %s=%s", i, key, redacted)
+		}
+		if exporter.ProxyURL != "" {
+			githubactions.Infof("exporter[%d] proxy=%s", i, redactURL(exporter.ProxyURL))
+		}
+		for _, pem := range []struct{ name, value string }{
+			{"ca-cert", exporter.CACert},
+			{"client-cert", exporter.ClientCert},
+			{"client-key", exporter.ClientKey},
+		} {
+			if pem.value != "" {
+				githubactions.Infof("exporter[%d] %s=%s", i, pem.name, describePEM(pem.value))
+			}
+		}
+		if r := exporter.Retry; r != nil {
+			githubactions.Infof("exporter[%d] retry enabled=%t initial-interval=%s max-interval=%s max-elapsed-time=%s",
+				i, r.Enabled, r.InitialInterval, r.MaxInterval, r.MaxElapsedTime)
+		}
+	}
+}
+
+func logAttributes(kind string, attrs []attribute.KeyValue) {
+	for _, kv := range attrs {
+		githubactions.Infof("%s %s=%s", kind, kv.Key, kv.Value.Emit())
+	}
+}
+
+// logTraceparent logs the fields of a parsed traceparent.
+func logTraceparent(traceparent string, spanContext trace.SpanContext) {
+	githubactions.Infof("Parsed traceparent %q: trace_id=%s parent_id=%s sampled=%t tracestate=%q",
+		traceparent, spanContext.TraceID(), spanContext.SpanID(), spanContext.IsSampled(), spanContext.TraceState().String())
+}
+
+// logExport logs the result of a span export with its gRPC status code, or OK.
+func logExport(endpoint string, spans int, err error) {
+	if err == nil {
+		githubactions.Infof("Exported %d span(s) to %s: OK", spans, endpoint)
+		return
+	}
+	code := "ERROR"
+	if s, ok := status.FromError(err); ok {
+		code = s.Code().String()
+	}
+	githubactions.Infof("Exported %d span(s) to %s: %s: %v", spans, endpoint, code, err)
+}
+
+// redactURL redacts the password of a URL, or the whole URL when it cannot be
+// parsed.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redacted
+	}
+	return u.Redacted()
+}
+
+// describePEM tells inline PEM content from a path without logging the content.
+func describePEM(value string) string {
+	if strings.Contains(value, "-----BEGIN") {
+		return "inline PEM"
+	}
+	return value
+}
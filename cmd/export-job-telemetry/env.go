@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+)
+
+// inputOrEnv returns the action input when set, otherwise the first of the
+// standard OpenTelemetry environment variables that is set.
+func inputOrEnv(input string, envs ...string) string {
+	if value := strings.TrimSpace(githubactions.GetInput(input)); value != "" {
+		return value
+	}
+	return lookupEnv(envs...)
+}
+
+// insecureValue returns the insecure setting of the input or the OpenTelemetry
+// environment, or an empty string when neither sets it.
+func insecureValue() string {
+	return inputOrEnv("otel-exporter-otlp-insecure", "OTEL_EXPORTER_OTLP_TRACES_INSECURE", "OTEL_EXPORTER_OTLP_INSECURE")
+}
+
+// insecureSet reports whether insecure is configured explicitly, so that
+// defaults such as those of act and the presets do not override it.
+func insecureSet() bool {
+	return insecureValue() != ""
+}
+
+func lookupEnv(envs ...string) string {
+	for _, env := range envs {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseKeyValuePairs parses the key=value pairs of the named input or
+// environment variable, warning about the malformed pairs it ignores.
+func parseKeyValuePairs(name, input string) map[string]string {
+	pairs, errs := telemetry.ParseKeyValuePairsWithErrors(input)
+	for _, err := range errs {
+		githubactions.Warningf("Ignoring malformed key=value pair in %s: %v", name, err)
+	}
+	return pairs
+}
+
+// parseEnvKeyValuePairs parses OTEL_EXPORTER_OTLP_HEADERS and
+// OTEL_RESOURCE_ATTRIBUTES style values, whose values are percent-encoded.
+func parseEnvKeyValuePairs(name, input string) map[string]string {
+	pairs := parseKeyValuePairs(name, input)
+	for k, v := range pairs {
+		if decoded, err := url.PathUnescape(v); err == nil {
+			pairs[k] = decoded
+		}
+	}
+	return pairs
+}
+
+// otelExporters expands base into one exporter per line of the endpoint input.
+// Protocols and headers are matched to the endpoints by line; a single protocol
+// applies to every endpoint, while headers are never shared between endpoints.
+func otelExporters(base telemetry.ExporterConfig) ([]telemetry.ExporterConfig, error) {
+	if strings.EqualFold(base.Type, telemetry.ExporterTypeConsole) || strings.EqualFold(base.Type, telemetry.ExporterTypeFile) {
+		return []telemetry.ExporterConfig{base}, nil
+	}
+
+	endpoints, signalURL := otelEndpoints()
+	if len(endpoints) == 0 {
+		endpoints = []string{""}
+	}
+	protocols := splitLines(inputOrEnv("otel-exporter-otlp-protocol", "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"))
+	headers, err := otelHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(protocols) > 1 && len(protocols) != len(endpoints) {
+		return nil, fmt.Errorf("otel-exporter-otlp-protocol has %d lines, expected 1 or one per endpoint (%d)", len(protocols), len(endpoints))
+	}
+	if len(headers) > len(endpoints) {
+		return nil, fmt.Errorf("otel-exporter-otlp-headers has %d lines, expected at most one per endpoint (%d)", len(headers), len(endpoints))
+	}
+
+	exporters := make([]telemetry.ExporterConfig, 0, len(endpoints))
+	for i, endpoint := range endpoints {
+		cfg := base
+		cfg.Endpoint = endpoint
+		cfg.SignalURL = signalURL
+		switch {
+		case len(protocols) == 1:
+			cfg.Protocol = protocols[0]
+		case len(protocols) > 1:
+			cfg.Protocol = protocols[i]
+		}
+		if i < len(headers) {
+			cfg.Headers = headers[i]
+		}
+		exporters = append(exporters, cfg)
+	}
+	if err := applyPreset(exporters); err != nil {
+		return nil, err
+	}
+	return exporters, nil
+}
+
+// otelEndpoints returns the lines of the endpoint input or, without it, of the
+// OpenTelemetry environment, and whether they are full traces URLs, as those of
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT are, rather than base URLs.
+func otelEndpoints() ([]string, bool) {
+	if endpoints := splitLines(githubactions.GetInput("otel-exporter-otlp-endpoint")); len(endpoints) > 0 {
+		return endpoints, false
+	}
+	if endpoints := splitLines(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")); len(endpoints) > 0 {
+		return endpoints, true
+	}
+	return splitLines(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")), false
+}
+
+// otelHeaders returns the headers of each endpoint, one line per endpoint. The
+// lines of otel-exporter-otlp-headers-file are merged over the inline headers
+// of the same endpoint. All header values are masked in the log.
+func otelHeaders() ([]map[string]string, error) {
+	headers := inlineOtelHeaders()
+	if path := strings.TrimSpace(githubactions.GetInput("otel-exporter-otlp-headers-file")); path != "" {
+		fileHeaders, err := readHeadersFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read otel-exporter-otlp-headers-file: %w", err)
+		}
+		for i, lineHeaders := range fileHeaders {
+			if i < len(headers) {
+				headers[i] = telemetry.MergeKeyValuePairs(headers[i], lineHeaders)
+			} else {
+				headers = append(headers, lineHeaders)
+			}
+		}
+	}
+
+	for _, lineHeaders := range headers {
+		for _, value := range lineHeaders {
+			if value != "" {
+				githubactions.AddMask(value)
+			}
+		}
+	}
+	return headers, nil
+}
+
+func inlineOtelHeaders() []map[string]string {
+	if input := githubactions.GetInput("otel-exporter-otlp-headers"); strings.TrimSpace(input) != "" {
+		lines := strings.Split(strings.TrimSpace(input), "\n")
+		headers := make([]map[string]string, 0, len(lines))
+		for _, line := range lines {
+			headers = append(headers, parseKeyValuePairs("otel-exporter-otlp-headers", strings.TrimSpace(line)))
+		}
+		return headers
+	}
+	for _, env := range []string{"OTEL_EXPORTER_OTLP_TRACES_HEADERS", "OTEL_EXPORTER_OTLP_HEADERS"} {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			return []map[string]string{parseEnvKeyValuePairs(env, value)}
+		}
+	}
+	return nil
+}
+
+// readHeadersFile reads headers in the format of otel-exporter-otlp-headers,
+// one line per endpoint. Blank lines and lines starting with # are skipped.
+func readHeadersFile(path string) ([]map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []map[string]string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		headers = append(headers, parseKeyValuePairs("otel-exporter-otlp-headers-file", line))
+	}
+	return headers, nil
+}
+
+func splitLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func otelResourceAttributes() map[string]string {
+	return telemetry.MergeKeyValuePairs(
+		parseEnvKeyValuePairs("OTEL_RESOURCE_ATTRIBUTES", os.Getenv("OTEL_RESOURCE_ATTRIBUTES")),
+		parseKeyValuePairs("otel-resource-attributes", githubactions.GetInput("otel-resource-attributes")),
+	)
+}
+
+func otelTimeout() (time.Duration, error) {
+	if timeout, err := parseDurationInput("otel-exporter-otlp-timeout"); err != nil || timeout > 0 {
+		return timeout, err
+	}
+
+	value := lookupEnv("OTEL_EXPORTER_OTLP_TRACES_TIMEOUT", "OTEL_EXPORTER_OTLP_TIMEOUT")
+	if value == "" {
+		return 0, nil
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms < 0 {
+		return 0, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_TIMEOUT: %q", value)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// importUnderscoreInputs makes inputs settable as e.g. INPUT_STARTED_AT, for
+// CI systems other than GitHub Actions whose variable names cannot contain
+// hyphens. The hyphenated variable takes precedence when both are set.
+func importUnderscoreInputs() {
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		input, ok := strings.CutPrefix(name, "INPUT_")
+		if !ok || !strings.Contains(input, "_") {
+			continue
+		}
+		hyphenated := "INPUT_" + strings.ReplaceAll(input, "_", "-")
+		if _, set := os.LookupEnv(hyphenated); !set {
+			os.Setenv(hyphenated, value)
+		}
+	}
+}
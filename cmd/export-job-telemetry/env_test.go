@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOtelEndpoints(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		want          []string
+		wantSignalURL bool
+	}{
+		{name: "none"},
+		{
+			name: "input",
+			env:  map[string]string{"INPUT_OTEL-EXPORTER-OTLP-ENDPOINT": "https://a.example.com\nhttps://b.example.com", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "https://c.example.com/v1/traces"},
+			want: []string{"https://a.example.com", "https://b.example.com"},
+		},
+		{
+			name:          "traces environment variable",
+			env:           map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "https://c.example.com/ingest", "OTEL_EXPORTER_OTLP_ENDPOINT": "https://d.example.com"},
+			want:          []string{"https://c.example.com/ingest"},
+			wantSignalURL: true,
+		},
+		{
+			name: "generic environment variable",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "https://d.example.com"},
+			want: []string{"https://d.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"INPUT_OTEL-EXPORTER-OTLP-ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"} {
+				t.Setenv(name, tt.env[name])
+			}
+			got, signalURL := otelEndpoints()
+			if !reflect.DeepEqual(got, tt.want) || signalURL != tt.wantSignalURL {
+				t.Errorf("otelEndpoints() = %q, %t, want %q, %t", got, signalURL, tt.want, tt.wantSignalURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"sync/atomic"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+// failOnError controls whether telemetry errors fail the step. Observability
+// plumbing must not block a build, so by default errors are only reported as
+// warnings and the action exits successfully.
+var (
+	failOnError    bool
+	reportedErrors atomic.Int64
+)
+
+func errorf(msg string, args ...any) {
+	if failOnError {
+		reportedErrors.Add(1)
+		githubactions.Errorf(msg, args...)
+		return
+	}
+	githubactions.Warningf(msg, args...)
+}
+
+// exitOnReportedErrors fails the step if any error was reported while
+// fail-on-error is set, e.g. an export that failed during shutdown.
+func exitOnReportedErrors() {
+	if failOnError && reportedErrors.Load() > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+const (
+	githubAPIPageSize = 100
+
+	// githubMaxRetries bounds the retries of a rate limited or failed GitHub
+	// API request, and githubMaxRetryWait the wait before each.
+	githubMaxRetries   = 4
+	githubMaxRetryWait = 2 * time.Minute
+
+	// githubSecondaryRateLimitWait is the wait GitHub recommends after a
+	// secondary rate limit without a Retry-After header.
+	githubSecondaryRateLimitWait = time.Minute
+)
+
+type githubClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	cache      *githubCache
+}
+
+// githubCache holds the responses of GitHub API requests by URL, so that a URL
+// requested again within the same invocation, such as by several clients of the
+// process, is revalidated with its ETag rather than downloaded again. It lives
+// only as long as the process, as the action runs once per job.
+type githubCache struct {
+	mu      sync.Mutex
+	entries map[string]githubCacheEntry
+}
+
+type githubCacheEntry struct {
+	etag string
+	body []byte
+	next string
+}
+
+var sharedGitHubCache = &githubCache{entries: make(map[string]githubCacheEntry)}
+
+func (c *githubCache) lookup(url string) (githubCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[url]
+	return entry, ok
+}
+
+func (c *githubCache) store(url string, entry githubCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[url] = entry
+}
+
+type workflowRun struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	RunNumber    int64      `json:"run_number"`
+	RunAttempt   int64      `json:"run_attempt"`
+	Event        string     `json:"event"`
+	Status       string     `json:"status"`
+	Conclusion   string     `json:"conclusion"`
+	HeadBranch   string     `json:"head_branch"`
+	HeadSHA      string     `json:"head_sha"`
+	HTMLURL      string     `json:"html_url"`
+	CreatedAt    *time.Time `json:"created_at"`
+	RunStartedAt *time.Time `json:"run_started_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+type workflowJob struct {
+	ID          int64          `json:"id"`
+	RunID       int64          `json:"run_id"`
+	RunAttempt  int64          `json:"run_attempt"`
+	Name        string         `json:"name"`
+	Status      string         `json:"status"`
+	Conclusion  string         `json:"conclusion"`
+	CreatedAt   *time.Time     `json:"created_at"`
+	StartedAt   *time.Time     `json:"started_at"`
+	CompletedAt *time.Time     `json:"completed_at"`
+	HTMLURL     string         `json:"html_url"`
+	RunnerName  string         `json:"runner_name"`
+	Labels      []string       `json:"labels"`
+	Steps       []workflowStep `json:"steps"`
+}
+
+type workflowStep struct {
+	Name        string     `json:"name"`
+	Number      int64      `json:"number"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// workflowRunTiming is the billable time of a workflow run, keyed by runner OS,
+// e.g. UBUNTU, MACOS or WINDOWS.
+type workflowRunTiming struct {
+	Billable map[string]struct {
+		TotalMS int64 `json:"total_ms"`
+		Jobs    int64 `json:"jobs"`
+		JobRuns []struct {
+			JobID      int64 `json:"job_id"`
+			DurationMS int64 `json:"duration_ms"`
+		} `json:"job_runs"`
+	} `json:"billable"`
+	RunDurationMS int64 `json:"run_duration_ms"`
+}
+
+type checkRunAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int64  `json:"start_line"`
+	EndLine         int64  `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title"`
+	Message         string `json:"message"`
+}
+
+func newGitHubClient(baseURL, token string) *githubClient {
+	return &githubClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		cache:      sharedGitHubCache,
+	}
+}
+
+// githubAPIURL returns the github-api-url input, falling back to the API of the
+// GitHub instance the workflow runs on.
+func githubAPIURL(params InputParams, ghctx *githubactions.GitHubContext) string {
+	if params.GitHubAPIURL != "" {
+		return params.GitHubAPIURL
+	}
+	return ghctx.APIURL
+}
+
+func (c *githubClient) get(ctx context.Context, path string, v any) error {
+	_, err := c.getPage(ctx, c.baseURL+path, v)
+	return err
+}
+
+// getPage decodes the response of a GET request into v and returns the URL of
+// the next page from the Link header, if any.
+func (c *githubClient) getPage(ctx context.Context, url string, v any) (string, error) {
+	header := make(http.Header)
+	cached, ok := c.cache.lookup(url)
+	if ok {
+		header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := c.send(ctx, url, header)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	entry := cached
+	if resp.StatusCode != http.StatusNotModified || !ok {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("GET %s: %w", resp.Request.URL.Path, err)
+		}
+		entry = githubCacheEntry{etag: resp.Header.Get("ETag"), body: body, next: nextPageURL(resp.Header.Get("Link"))}
+		if entry.etag != "" {
+			c.cache.store(url, entry)
+		}
+	}
+	return entry.next, json.Unmarshal(entry.body, v)
+}
+
+// list collects the items of every page of a list request, following the next
+// links of the responses. decode returns the items of a page.
+func list[T any](ctx context.Context, c *githubClient, path string, decode func([]byte) ([]T, error)) ([]T, error) {
+	var items []T
+	for url := c.baseURL + path; url != ""; {
+		var page json.RawMessage
+		next, err := c.getPage(ctx, url, &page)
+		if err != nil {
+			return nil, err
+		}
+		pageItems, err := decode(page)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, pageItems...)
+		url = next
+	}
+	return items, nil
+}
+
+func (c *githubClient) open(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// send makes a GET request, waiting out rate limits and retrying server
+// errors. It returns the response if it is OK or, for a conditional request,
+// not modified.
+func (c *githubClient) send(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, values := range header {
+			req.Header[k] = values
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK || (resp.StatusCode == http.StatusNotModified && header.Get("If-None-Match") != "") {
+			return resp, nil
+		}
+
+		wait, retry := githubRetryWait(resp, attempt)
+		resp.Body.Close()
+		err = fmt.Errorf("GET %s: unexpected status %s", req.URL.Path, resp.Status)
+		if !retry || attempt == githubMaxRetries {
+			return nil, err
+		}
+		if wait > githubMaxRetryWait {
+			return nil, fmt.Errorf("%w, retry in %s", err, wait.Round(time.Second))
+		}
+
+		githubactions.Infof("%v, retrying in %s", err, wait.Round(time.Second))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// githubRetryWait decides whether a failed request is retried and after how
+// long, following GitHub's guidance for primary and secondary rate limits:
+// honour Retry-After, wait for the reset when no requests remain, and
+// otherwise wait at least a minute, doubling on each attempt. Server errors
+// are retried with a shorter exponential backoff.
+func githubRetryWait(resp *http.Response, attempt int) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			return max(time.Until(time.Unix(reset, 0))+time.Second, 0), true
+		}
+		// A 403 without rate limit headers is only a secondary rate limit if
+		// the message says so.
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if resp.StatusCode == http.StatusTooManyRequests || strings.Contains(strings.ToLower(string(body)), "rate limit") {
+			return githubSecondaryRateLimitWait << attempt, true
+		}
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return time.Second << attempt, true
+	}
+	return 0, false
+}
+
+// nextPageURL returns the URL of the next page from a Link header, e.g.
+// <https://api.github.com/...&page=2>; rel="next", or "" on the last page.
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.ReplaceAll(strings.TrimSpace(param), " ", "") == `rel="next"` {
+				return target[1 : len(target)-1]
+			}
+		}
+	}
+	return ""
+}
+
+// serverTime returns the time of the GitHub API server, from the Date header of
+// a rate limit request, which does not count against the rate limit, and the
+// local time halfway through the request it corresponds to.
+func (c *githubClient) serverTime(ctx context.Context) (server, local time.Time, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/rate_limit", nil)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	sent := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	resp.Body.Close()
+	received := time.Now()
+
+	server, err = http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("GET /rate_limit: invalid Date header: %w", err)
+	}
+	return server, sent.Add(received.Sub(sent) / 2), nil
+}
+
+func (c *githubClient) getWorkflowRun(ctx context.Context, owner, repo string, runID int64) (workflowRun, error) {
+	var run workflowRun
+	err := c.get(ctx, fmt.Sprintf("/repos/%s/%s/actions/runs/%d", owner, repo, runID), &run)
+	return run, err
+}
+
+func (c *githubClient) getWorkflowRunTiming(ctx context.Context, owner, repo string, runID int64) (workflowRunTiming, error) {
+	var timing workflowRunTiming
+	err := c.get(ctx, fmt.Sprintf("/repos/%s/%s/actions/runs/%d/timing", owner, repo, runID), &timing)
+	return timing, err
+}
+
+func (c *githubClient) listJobsForRunAttempt(ctx context.Context, owner, repo string, runID, attempt int64) ([]workflowJob, error) {
+	path := fmt.Sprintf("/repos/%s/%s/actions/runs/%d/attempts/%d/jobs?per_page=%d", owner, repo, runID, attempt, githubAPIPageSize)
+	return list(ctx, c, path, func(page []byte) ([]workflowJob, error) {
+		var result struct {
+			Jobs []workflowJob `json:"jobs"`
+		}
+		err := json.Unmarshal(page, &result)
+		return result.Jobs, err
+	})
+}
+
+// listJobAnnotations lists the annotations of a job. The id of a workflow job
+// is also the id of its check run.
+func (c *githubClient) listJobAnnotations(ctx context.Context, owner, repo string, jobID int64) ([]checkRunAnnotation, error) {
+	path := fmt.Sprintf("/repos/%s/%s/check-runs/%d/annotations?per_page=%d", owner, repo, jobID, githubAPIPageSize)
+	return list(ctx, c, path, func(page []byte) ([]checkRunAnnotation, error) {
+		var annotations []checkRunAnnotation
+		err := json.Unmarshal(page, &annotations)
+		return annotations, err
+	})
+}
+
+// downloadJobLog downloads the plain text log of a job. GitHub redirects to a
+// short-lived URL that the HTTP client follows without the token. The log is
+// only available once the job has completed.
+func (c *githubClient) downloadJobLog(ctx context.Context, owner, repo string, jobID int64) (io.ReadCloser, error) {
+	return c.open(ctx, fmt.Sprintf("/repos/%s/%s/actions/jobs/%d/logs", owner, repo, jobID))
+}
+
+// findCurrentJob picks the job this action is running in. The runner name is
+// unique to a job while it is in progress; the job name is used as a fallback.
+func findCurrentJob(jobs []workflowJob, runnerName, jobName string) (workflowJob, bool) {
+	if runnerName != "" {
+		for _, job := range jobs {
+			if job.RunnerName == runnerName && job.Status == "in_progress" {
+				return job, true
+			}
+		}
+	}
+	if jobName != "" {
+		for _, job := range jobs {
+			if job.Name == jobName {
+				return job, true
+			}
+		}
+	}
+	return workflowJob{}, false
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestGitHubClient returns a client of the API served by handler, with a
+// cache of its own.
+func newTestGitHubClient(t *testing.T, handler http.HandlerFunc) *githubClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := newGitHubClient(server.URL+"/", "secret")
+	client.cache = &githubCache{entries: make(map[string]githubCacheEntry)}
+	return client
+}
+
+func TestGitHubClientPagination(t *testing.T) {
+	var client *githubClient
+	client = newTestGitHubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want Bearer secret", got)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?per_page=100&page=2>; rel="next", <%s%s?per_page=100&page=2>; rel="last"`, client.baseURL, r.URL.Path, client.baseURL, r.URL.Path))
+		}
+		fmt.Fprintf(w, `{"total_count":2,"jobs":[{"id":%d,"name":"job %d"}]}`, page+1, page+1)
+	})
+
+	jobs, err := client.listJobsForRunAttempt(context.Background(), "octo", "repo", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].Name != "job 1" || jobs[1].Name != "job 3" {
+		t.Errorf("listJobsForRunAttempt() = %+v, want the jobs of both pages", jobs)
+	}
+}
+
+func TestGitHubClientETag(t *testing.T) {
+	var requests, notModified atomic.Int32
+	client := newTestGitHubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"id":1,"name":"CI"}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		run, err := client.getWorkflowRun(context.Background(), "octo", "repo", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run.Name != "CI" {
+			t.Errorf("request %d: getWorkflowRun() = %+v, want the cached run", i, run)
+		}
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("made %d requests, %d not modified, want 2 and 1", requests.Load(), notModified.Load())
+	}
+}
+
+func TestGitHubClientRetry(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestGitHubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"id":1}`)
+	})
+
+	if _, err := client.getWorkflowRun(context.Background(), "octo", "repo", 1); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("made %d requests, want 3", requests.Load())
+	}
+}
+
+func TestGitHubClientErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		requests int32
+		want     string
+	}{
+		{
+			name:     "not found",
+			handler:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) },
+			requests: 1,
+			want:     "unexpected status 404",
+		},
+		{
+			name: "retries exhausted",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			requests: githubMaxRetries + 1,
+			want:     "unexpected status 429",
+		},
+		{
+			name: "wait too long",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			requests: 1,
+			want:     "retry in 1h0m0s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			client := newTestGitHubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				tt.handler(w, r)
+			})
+			_, err := client.getWorkflowRun(context.Background(), "octo", "repo", 1)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("getWorkflowRun() error = %v, want %q", err, tt.want)
+			}
+			if requests.Load() != tt.requests {
+				t.Errorf("made %d requests, want %d", requests.Load(), tt.requests)
+			}
+		})
+	}
+}
+
+func TestGitHubRetryWait(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(30*time.Second).Unix(), 10)
+	tests := []struct {
+		name    string
+		status  int
+		header  map[string]string
+		body    string
+		attempt int
+		min     time.Duration
+		max     time.Duration
+		retry   bool
+	}{
+		{name: "retry after", status: http.StatusForbidden, header: map[string]string{"Retry-After": "7"}, min: 7 * time.Second, max: 7 * time.Second, retry: true},
+		{name: "primary rate limit", status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}, min: 25 * time.Second, max: 32 * time.Second, retry: true},
+		{name: "secondary rate limit", status: http.StatusForbidden, body: `{"message":"You have exceeded a secondary rate limit."}`, attempt: 1, min: 2 * time.Minute, max: 2 * time.Minute, retry: true},
+		{name: "too many requests", status: http.StatusTooManyRequests, min: time.Minute, max: time.Minute, retry: true},
+		{name: "forbidden", status: http.StatusForbidden, body: `{"message":"Resource not accessible by integration"}`},
+		{name: "server error", status: http.StatusBadGateway, attempt: 2, min: 4 * time.Second, max: 4 * time.Second, retry: true},
+		{name: "not found", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(tt.body))}
+			for k, v := range tt.header {
+				resp.Header.Set(k, v)
+			}
+			wait, retry := githubRetryWait(resp, tt.attempt)
+			if retry != tt.retry || wait < tt.min || wait > tt.max {
+				t.Errorf("githubRetryWait() = %s, %t, want %t within [%s, %s]", wait, retry, tt.retry, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{link: "", want: ""},
+		{link: `<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, want: "https://api.github.com/x?page=2"},
+		{link: `<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=3>; rel = "next"`, want: "https://api.github.com/x?page=3"},
+		{link: `<https://api.github.com/x?page=1>; rel="first", <https://api.github.com/x?page=1>; rel="prev"`, want: ""},
+		{link: `https://api.github.com/x?page=2; rel="next"`, want: ""},
+	}
+	for _, tt := range tests {
+		if got := nextPageURL(tt.link); got != tt.want {
+			t.Errorf("nextPageURL(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestFindCurrentJob(t *testing.T) {
+	jobs := []workflowJob{
+		{ID: 1, Name: "build", RunnerName: "runner-1", Status: "completed"},
+		{ID: 2, Name: "test", RunnerName: "runner-1", Status: "in_progress"},
+		{ID: 3, Name: "lint", RunnerName: "runner-2", Status: "in_progress"},
+	}
+	tests := []struct {
+		name       string
+		runnerName string
+		jobName    string
+		want       int64
+		found      bool
+	}{
+		{name: "by runner", runnerName: "runner-1", jobName: "build", want: 2, found: true},
+		{name: "by job name", runnerName: "runner-9", jobName: "lint", want: 3, found: true},
+		{name: "not found", runnerName: "runner-9", jobName: "deploy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, found := findCurrentJob(jobs, tt.runnerName, tt.jobName)
+			if found != tt.found || job.ID != tt.want {
+				t.Errorf("findCurrentJob() = %d, %t, want %d, %t", job.ID, found, tt.want, tt.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// gitlabProvider reads the job from the CI_* environment of GitLab CI.
+type gitlabProvider struct{}
+
+// gitlabConclusions maps CI_JOB_STATUS, which is set in after_script, to the
+// GitHub job conclusions used for the span status.
+var gitlabConclusions = map[string]string{
+	"success":  telemetry.ConclusionSuccess,
+	"failed":   telemetry.ConclusionFailure,
+	"canceled": telemetry.ConclusionCancelled,
+}
+
+func (gitlabProvider) Name() string {
+	return providerGitLab
+}
+
+func (gitlabProvider) ApplyDefaults(params *InputParams) {
+	if params.StartedAt == "" {
+		params.StartedAt = os.Getenv("CI_JOB_STARTED_AT")
+	}
+	if params.JobStatus == "" {
+		if conclusion, ok := gitlabConclusions[os.Getenv("CI_JOB_STATUS")]; ok {
+			params.JobStatus = conclusion
+		} else {
+			params.JobStatus = os.Getenv("CI_JOB_STATUS")
+		}
+	}
+	if params.JobName == "" {
+		params.JobName = os.Getenv("CI_JOB_NAME")
+	}
+}
+
+func (gitlabProvider) SpanNameValues(params InputParams) map[string]string {
+	job := params.JobName
+	if job == "" {
+		job = os.Getenv("CI_JOB_NAME")
+	}
+	// CI_RUNNER_EXECUTABLE_ARCH is of the form linux/amd64.
+	runnerOS, _, _ := strings.Cut(os.Getenv("CI_RUNNER_EXECUTABLE_ARCH"), "/")
+	return map[string]string{
+		"workflow":    os.Getenv("CI_PIPELINE_NAME"),
+		"job":         job,
+		"repository":  os.Getenv("CI_PROJECT_PATH"),
+		"ref":         os.Getenv("CI_COMMIT_REF_NAME"),
+		"sha":         os.Getenv("CI_COMMIT_SHA"),
+		"actor":       os.Getenv("GITLAB_USER_LOGIN"),
+		"event":       os.Getenv("CI_PIPELINE_SOURCE"),
+		"run_id":      os.Getenv("CI_PIPELINE_ID"),
+		"run_number":  os.Getenv("CI_PIPELINE_IID"),
+		"run_attempt": "",
+		"runner_os":   runnerOS,
+		"status":      params.JobStatus,
+	}
+}
+
+func (gitlabProvider) Attributes() []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+	addString := func(key, env string) {
+		if value := os.Getenv(env); value != "" {
+			attributes = append(attributes, attribute.String(key, value))
+		}
+	}
+	addInt := func(key, env string) {
+		if value, err := strconv.ParseInt(os.Getenv(env), 10, 64); err == nil {
+			attributes = append(attributes, attribute.Int64(key, value))
+		}
+	}
+
+	addString("ci.github.workflow.name", "CI_PIPELINE_NAME")
+	addInt("ci.github.workflow.run.id", "CI_PIPELINE_ID")
+	addInt("ci.github.workflow.run.number", "CI_PIPELINE_IID")
+	addString("ci.github.workflow.job.name", "CI_JOB_NAME")
+	addInt("ci.github.workflow.job.id", "CI_JOB_ID")
+	addString("ci.github.workflow.job.url", "CI_JOB_URL")
+	addString("ci.github.repository", "CI_PROJECT_PATH")
+	addString("ci.github.repository.url", "CI_PROJECT_URL")
+	addString("ci.github.ref.name", "CI_COMMIT_REF_NAME")
+	if os.Getenv("CI_COMMIT_TAG") != "" {
+		attributes = append(attributes, attribute.String("ci.github.ref.type", "tag"))
+	} else if os.Getenv("CI_COMMIT_BRANCH") != "" {
+		attributes = append(attributes, attribute.String("ci.github.ref.type", "branch"))
+	}
+	addString("ci.github.sha", "CI_COMMIT_SHA")
+	addInt("ci.github.pull_request.number", "CI_MERGE_REQUEST_IID")
+	addString("ci.github.pull_request.title", "CI_MERGE_REQUEST_TITLE")
+	addString("ci.gitlab.user.login", "GITLAB_USER_LOGIN")
+	addString("ci.gitlab.pipeline.source", "CI_PIPELINE_SOURCE")
+	addString("ci.gitlab.pipeline.url", "CI_PIPELINE_URL")
+	addString("ci.gitlab.job.stage", "CI_JOB_STAGE")
+	addString("ci.gitlab.runner.id", "CI_RUNNER_ID")
+	addString("ci.gitlab.runner.description", "CI_RUNNER_DESCRIPTION")
+	addString("ci.gitlab.runner.tags", "CI_RUNNER_TAGS")
+	return attributes
+}
+
+func (gitlabProvider) RunAttributes() []attribute.KeyValue {
+	return nil
+}
+
+func (gitlabProvider) TraceID() (trace.TraceID, bool) {
+	pipelineID := os.Getenv("CI_PIPELINE_ID")
+	if pipelineID == "" {
+		return trace.TraceID{}, false
+	}
+	return telemetry.PipelineTraceID(os.Getenv("CI_SERVER_URL")+"/"+providerGitLab, pipelineID), true
+}
+
+// JobSpanID derives the span id from the pipeline and job id. A retried job
+// gets a new job id, which takes the place of the run attempt.
+func (gitlabProvider) JobSpanID(jobName string) (trace.SpanID, bool) {
+	pipelineID, err := strconv.ParseInt(os.Getenv("CI_PIPELINE_ID"), 10, 64)
+	if err != nil {
+		return trace.SpanID{}, false
+	}
+	jobID, err := strconv.ParseInt(os.Getenv("CI_JOB_ID"), 10, 64)
+	if err != nil {
+		return trace.SpanID{}, false
+	}
+	return telemetry.JobSpanID(os.Getenv("CI_PROJECT_PATH"), pipelineID, jobID, jobName), true
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tokenPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+
+type InputParams struct {
+	Provider    Provider
+	Mode        string
+	Phase       string
+	ReplayFiles []string
+
+	Traceparent         string
+	Tracestate          string
+	TraceparentFromStep string
+	ParentRelationship  string
+	IssueTraceparent    string
+	SpanLinks           []trace.Link
+	SpanEvents          []telemetry.SpanEvent
+	TriggerCommentURL   string
+
+	OtelResourceAttrs []attribute.KeyValue
+	Baggage           baggage.Baggage
+	AttributeSchema   telemetry.AttributeSchema
+	OtelServiceName   string
+	BaselineAttrs     []attribute.KeyValue
+	Exporters         []telemetry.ExporterConfig
+	Sampler           sdktrace.Sampler
+
+	OIDCAuth             bool
+	OIDCAudience         string
+	OIDCTokenExchangeURL string
+
+	StartedAt string
+	CreatedAt string
+	JobStatus string
+	JobName   string
+	Matrix    string
+	SpanName  string
+	SpanKind  string
+	RunID     string
+	TraceURL  string
+
+	RunnerLabels        []string
+	RunnerCostPerMinute map[string]float64
+
+	ErrorConclusions []string
+	StatusMapping    telemetry.StatusMapping
+	StatusMessage    string
+
+	ArtifactName             string
+	ArtifactSizeBytes        string
+	ArtifactDigest           string
+	ArtifactCount            string
+	CacheHit                 string
+	CacheSizeBytes           string
+	ExportResourceUsage      bool
+	NestReusableWorkflows    bool
+	VerifyConnectivity       bool
+	BuildTool                string
+	BuildToolVersion         string
+	GitHubRateLimitRemaining string
+	GitHubRateLimitReset     string
+	RetentionTier            string
+
+	AutoDetect          bool
+	DeterministicSpanID bool
+	EphemeralRunner     bool
+	ExportAnnotations   bool
+	ExportBillableTime  bool
+	ExportLogs          bool
+	ExportMetrics       bool
+	ExportQueueSpan     bool
+	ExportSteps         bool
+	GitHubToken         string
+	GitHubAPIURL        string
+	CorrectClockSkew    bool
+	JobSummary          bool
+	LogSpan             bool
+	Debug               bool
+}
+
+func parseInputParams() (InputParams, error) {
+	params := InputParams{
+		Mode:        strings.TrimSpace(githubactions.GetInput("mode")),
+		Phase:       strings.TrimSpace(githubactions.GetInput("phase")),
+		ReplayFiles: splitLines(githubactions.GetInput("replay-files")),
+
+		Traceparent:         githubactions.GetInput("traceparent"),
+		Tracestate:          strings.TrimSpace(githubactions.GetInput("tracestate")),
+		TraceparentFromStep: strings.TrimSpace(githubactions.GetInput("traceparent-from-step")),
+		ParentRelationship:  strings.TrimSpace(githubactions.GetInput("parent-relationship")),
+		IssueTraceparent:    strings.TrimSpace(githubactions.GetInput("issue-traceparent")),
+		TriggerCommentURL:   strings.TrimSpace(githubactions.GetInput("trigger-comment-url")),
+
+		OtelServiceName: inputOrEnv("otel-service-name", "OTEL_SERVICE_NAME"),
+
+		OIDCAudience:         strings.TrimSpace(githubactions.GetInput("oidc-audience")),
+		OIDCTokenExchangeURL: strings.TrimSpace(githubactions.GetInput("oidc-token-exchange-url")),
+
+		StartedAt: githubactions.GetInput("started-at"),
+		CreatedAt: githubactions.GetInput("created-at"),
+		JobStatus: githubactions.GetInput("job-status"),
+		JobName:   githubactions.GetInput("job-name"),
+		Matrix:    githubactions.GetInput("matrix"),
+		SpanName:  githubactions.GetInput("span-name"),
+		SpanKind:  githubactions.GetInput("span-kind"),
+		RunID:     strings.TrimSpace(githubactions.GetInput("run-id")),
+		TraceURL:  strings.TrimSpace(githubactions.GetInput("trace-url")),
+
+		RunnerLabels: parseListInput("runner-labels"),
+
+		ErrorConclusions: errorConclusions(),
+		StatusMessage:    strings.TrimSpace(githubactions.GetInput("status-message")),
+
+		ArtifactName:             strings.TrimSpace(githubactions.GetInput("artifact-name")),
+		ArtifactSizeBytes:        strings.TrimSpace(githubactions.GetInput("artifact-size-bytes")),
+		ArtifactDigest:           strings.TrimSpace(githubactions.GetInput("artifact-digest")),
+		ArtifactCount:            strings.TrimSpace(githubactions.GetInput("artifact-count")),
+		CacheHit:                 strings.TrimSpace(githubactions.GetInput("cache-hit")),
+		CacheSizeBytes:           strings.TrimSpace(githubactions.GetInput("cache-size-bytes")),
+		BuildTool:                strings.TrimSpace(githubactions.GetInput("build-tool")),
+		BuildToolVersion:         strings.TrimSpace(githubactions.GetInput("build-tool-version")),
+		GitHubRateLimitRemaining: strings.TrimSpace(githubactions.GetInput("github-rate-limit-remaining")),
+		GitHubRateLimitReset:     strings.TrimSpace(githubactions.GetInput("github-rate-limit-reset")),
+		RetentionTier:            strings.TrimSpace(githubactions.GetInput("retention-tier")),
+
+		GitHubToken: strings.TrimSpace(githubactions.GetInput("github-token")),
+	}
+
+	boolInputs := []struct {
+		name         string
+		value        *bool
+		defaultValue bool
+	}{
+		{"oidc-auth", &params.OIDCAuth, false},
+		{"export-resource-usage", &params.ExportResourceUsage, false},
+		{"nest-reusable-workflows", &params.NestReusableWorkflows, false},
+		{"verify-connectivity", &params.VerifyConnectivity, false},
+		{"auto-detect", &params.AutoDetect, true},
+		{"deterministic-span-id", &params.DeterministicSpanID, false},
+		{"ephemeral-runner", &params.EphemeralRunner, false},
+		{"export-annotations", &params.ExportAnnotations, false},
+		{"export-billable-time", &params.ExportBillableTime, false},
+		{"export-logs", &params.ExportLogs, false},
+		{"correct-clock-skew", &params.CorrectClockSkew, false},
+		{"export-metrics", &params.ExportMetrics, false},
+		{"export-queue-span", &params.ExportQueueSpan, false},
+		{"export-steps", &params.ExportSteps, false},
+		{"job-summary", &params.JobSummary, false},
+	}
+	var err error
+	for _, input := range boolInputs {
+		if *input.value, err = parseBoolInputWithDefault(input.name, input.defaultValue); err != nil {
+			return InputParams{}, err
+		}
+	}
+
+	if params.Provider, err = ciProvider(); err != nil {
+		return InputParams{}, err
+	}
+	if params.SpanLinks, err = spanLinks(); err != nil {
+		return InputParams{}, err
+	}
+	if params.SpanEvents, err = spanEvents(); err != nil {
+		return InputParams{}, err
+	}
+	if params.OtelResourceAttrs, err = typedAttributes("otel-resource-attributes", otelResourceAttributes()); err != nil {
+		return InputParams{}, err
+	}
+	if params.Baggage, err = parseBaggage(); err != nil {
+		return InputParams{}, err
+	}
+	if params.AttributeSchema, err = attributeSchema(); err != nil {
+		return InputParams{}, err
+	}
+	if params.BaselineAttrs, err = typedAttributes("baseline-attributes", parseKeyValuePairs("baseline-attributes", githubactions.GetInput("baseline-attributes"))); err != nil {
+		return InputParams{}, err
+	}
+	if params.Exporters, err = exporterConfigs(); err != nil {
+		return InputParams{}, err
+	}
+	if params.StatusMapping, err = statusMapping(); err != nil {
+		return InputParams{}, err
+	}
+	if params.Sampler, err = otelSampler(params.StatusMapping); err != nil {
+		return InputParams{}, err
+	}
+	if params.RunnerCostPerMinute, err = runnerCostPerMinute(); err != nil {
+		return InputParams{}, err
+	}
+	if params.GitHubAPIURL, err = githubAPIURLInput(); err != nil {
+		return InputParams{}, err
+	}
+	return params, nil
+}
+
+// exporterConfigs returns one exporter per endpoint, configured from the
+// exporter inputs and the OpenTelemetry environment.
+func exporterConfigs() ([]telemetry.ExporterConfig, error) {
+	timeout, err := otelTimeout()
+	if err != nil {
+		return nil, err
+	}
+	insecure, err := parseBoolValue("otel-exporter-otlp-insecure", insecureValue())
+	if err != nil {
+		return nil, err
+	}
+	autoAppendPort, err := parseBoolInput("auto-append-port")
+	if err != nil {
+		return nil, err
+	}
+	retry, err := otelRetry()
+	if err != nil {
+		return nil, err
+	}
+	return otelExporters(telemetry.ExporterConfig{
+		Type:           strings.TrimSpace(githubactions.GetInput("exporter")),
+		FilePath:       strings.TrimSpace(githubactions.GetInput("exporter-file-path")),
+		Timeout:        timeout,
+		Insecure:       insecure,
+		Compression:    inputOrEnv("otel-exporter-otlp-compression", "OTEL_EXPORTER_OTLP_TRACES_COMPRESSION", "OTEL_EXPORTER_OTLP_COMPRESSION"),
+		ProxyURL:       proxyURL(),
+		CACert:         inputOrEnv("otel-exporter-otlp-ca-cert", "OTEL_EXPORTER_OTLP_TRACES_CERTIFICATE", "OTEL_EXPORTER_OTLP_CERTIFICATE"),
+		ClientCert:     inputOrEnv("otel-exporter-otlp-client-cert", "OTEL_EXPORTER_OTLP_TRACES_CLIENT_CERTIFICATE", "OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE"),
+		ClientKey:      inputOrEnv("otel-exporter-otlp-client-key", "OTEL_EXPORTER_OTLP_TRACES_CLIENT_KEY", "OTEL_EXPORTER_OTLP_CLIENT_KEY"),
+		Retry:          retry,
+		AutoAppendPort: autoAppendPort,
+	})
+}
+
+func spanLinks() ([]trace.Link, error) {
+	return telemetry.ParseSpanLinks(githubactions.GetInput("span-links"))
+}
+
+func spanEvents() ([]telemetry.SpanEvent, error) {
+	return telemetry.ParseSpanEvents(githubactions.GetInput("span-events"))
+}
+
+func attributeSchema() (telemetry.AttributeSchema, error) {
+	return telemetry.ParseAttributeSchema(githubactions.GetInput("attribute-schema"))
+}
+
+func parseBaggage() (baggage.Baggage, error) {
+	return telemetry.ParseBaggage(strings.TrimSpace(githubactions.GetInput("baggage")))
+}
+
+func typedAttributes(name string, pairs map[string]string) ([]attribute.KeyValue, error) {
+	attributes, err := telemetry.TypedAttributes(pairs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return attributes, nil
+}
+
+func errorConclusions() []string {
+	input := strings.TrimSpace(githubactions.GetInput("error-conclusions"))
+	if input == "" {
+		return telemetry.DefaultErrorConclusions
+	}
+	return telemetry.ParseConclusions(input)
+}
+
+func statusMapping() (telemetry.StatusMapping, error) {
+	mapping, err := telemetry.ParseStatusMapping(parseKeyValuePairs("status-mapping", githubactions.GetInput("status-mapping")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid status-mapping: %w", err)
+	}
+	return mapping, nil
+}
+
+// proxyURL returns the otel-exporter-proxy-url input, masking its password in
+// the log.
+func proxyURL() string {
+	value := strings.TrimSpace(githubactions.GetInput("otel-exporter-proxy-url"))
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok && password != "" {
+			githubactions.AddMask(password)
+		}
+	}
+	return value
+}
+
+// otelSampler returns the configured sampler which, with always-sample-errors,
+// keeps every span whose conclusion maps to an Error status, as the error
+// conclusions and mapping decide it.
+func otelSampler(mapping telemetry.StatusMapping) (sdktrace.Sampler, error) {
+	sampler, err := telemetry.ParseSampler(
+		inputOrEnv("otel-traces-sampler", "OTEL_TRACES_SAMPLER"),
+		inputOrEnv("otel-traces-sampler-arg", "OTEL_TRACES_SAMPLER_ARG"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	alwaysSampleErrors, err := parseBoolInput("always-sample-errors")
+	if err != nil {
+		return nil, err
+	}
+	if alwaysSampleErrors {
+		sampler = telemetry.SampleConclusions(sampler, errorConclusions(), mapping)
+	}
+	return sampler, nil
+}
+
+// otelRetry returns the retry policy of the retry inputs, which logs each span
+// export retry it makes. Without any of them, nil leaves retries to the OTLP
+// exporters' default policy.
+func otelRetry() (*telemetry.RetryConfig, error) {
+	configured := false
+	for _, name := range []string{
+		"otel-exporter-otlp-retry-enabled",
+		"otel-exporter-otlp-retry-initial-interval",
+		"otel-exporter-otlp-retry-max-interval",
+		"otel-exporter-otlp-retry-max-elapsed-time",
+	} {
+		configured = configured || strings.TrimSpace(githubactions.GetInput(name)) != ""
+	}
+	if !configured {
+		return nil, nil
+	}
+
+	retry := &telemetry.RetryConfig{
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			githubactions.Warningf("Span export attempt %d failed, retrying in %s: %v", attempt, delay, err)
+		},
+	}
+	var err error
+	if retry.Enabled, err = parseBoolInputWithDefault("otel-exporter-otlp-retry-enabled", true); err != nil {
+		return nil, err
+	}
+	if retry.InitialInterval, err = parseDurationInput("otel-exporter-otlp-retry-initial-interval"); err != nil {
+		return nil, err
+	}
+	if retry.MaxInterval, err = parseDurationInput("otel-exporter-otlp-retry-max-interval"); err != nil {
+		return nil, err
+	}
+	if retry.MaxElapsedTime, err = parseDurationInput("otel-exporter-otlp-retry-max-elapsed-time"); err != nil {
+		return nil, err
+	}
+	return retry, nil
+}
+
+// parseDurationInput parses a duration such as 10s, or a number of
+// milliseconds like the OTEL_EXPORTER_OTLP_TIMEOUT environment variable.
+func parseDurationInput(name string) (time.Duration, error) {
+	value := strings.TrimSpace(githubactions.GetInput(name))
+	if value == "" {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms >= 0 {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return d, nil
+}
+
+// parseListInput parses a comma-separated input, dropping empty entries.
+func parseListInput(name string) []string {
+	var values []string
+	for _, value := range strings.Split(githubactions.GetInput(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func parseBoolInput(name string) (bool, error) {
+	return parseBoolValue(name, strings.TrimSpace(githubactions.GetInput(name)))
+}
+
+func parseBoolInputWithDefault(name string, defaultValue bool) (bool, error) {
+	value := strings.TrimSpace(githubactions.GetInput(name))
+	if value == "" {
+		return defaultValue, nil
+	}
+	return parseBoolValue(name, value)
+}
+
+func parseBoolValue(name, value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return b, nil
+}
+
+func parseRateLimitReset(value string) (int64, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return seconds, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("expected epoch seconds or RFC3339 time, got %q", value)
+	}
+	return t.Unix(), nil
+}
+
+func githubAPIURLInput() (string, error) {
+	apiURL := strings.TrimSpace(githubactions.GetInput("github-api-url"))
+	if apiURL == "" {
+		return "", nil
+	}
+	if err := validateURL(apiURL); err != nil {
+		return "", fmt.Errorf("invalid github-api-url: %w", err)
+	}
+	return apiURL, nil
+}
+
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q in %q", u.Scheme, rawURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host in %q", rawURL)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// failedStepLogs downloads the log of job and returns the output of its failed
+// steps. Each step log is correlated with its step span when steps are
+// exported, and with the job span otherwise.
+func failedStepLogs(ctx context.Context, client *githubClient, owner, repo string, job workflowJob, jobSpan trace.SpanContext, stepSpans []trace.SpanContext) ([]telemetry.StepLog, error) {
+	steps := job.telemetrySteps()
+
+	var failed []int
+	for i, step := range steps {
+		if step.Conclusion == "failure" {
+			failed = append(failed, i)
+		}
+	}
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	body, err := client.downloadJobLog(ctx, owner, repo, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	lines, err := telemetry.ParseJobLog(body)
+	if err != nil {
+		return nil, err
+	}
+
+	stepLines := telemetry.StepLogLines(lines, steps)
+	logs := make([]telemetry.StepLog, 0, len(failed))
+	for _, i := range failed {
+		spanContext := jobSpan
+		if i < len(stepSpans) && stepSpans[i].IsValid() {
+			spanContext = stepSpans[i]
+		}
+		logs = append(logs, telemetry.StepLog{
+			SpanContext: spanContext,
+			Step:        steps[i],
+			Lines:       stepLines[i],
+		})
+	}
+	return logs, nil
+}
+
+func exportLogs(ctx context.Context, params InputParams, res *resource.Resource, logs []telemetry.StepLog) {
+	if len(logs) == 0 {
+		return
+	}
+	for _, exporter := range params.Exporters {
+		if exporter.Type == telemetry.ExporterTypeFile {
+			githubactions.Warningf("Skipping logs: the file exporter only supports traces")
+			continue
+		}
+		if err := telemetry.ExportStepLogs(ctx, exporter, res, logs); err != nil {
+			errorf("failed to export logs to %s: %v", exporter.Name(), err)
+			continue
+		}
+		githubactions.Infof("Exported the logs of %d failed step(s) to %s", len(logs), exporter.Name())
+	}
+}
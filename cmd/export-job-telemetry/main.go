@@ -2,22 +2,31 @@ package main
 
 import (
 	"context"
-	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
 	"github.com/sethvargo/go-githubactions"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
-const actionName = "export-job-telemetry"
+const (
+	actionName = "export-job-telemetry"
+
+	modeJob         = "job"
+	modeWorkflowRun = "workflow-run"
+	modeReplay      = "replay"
+
+	ephemeralFlushTimeout = 10 * time.Second
+	connectivityTimeout   = 10 * time.Second
+)
 
 var (
 	BUILD_VERSION string
@@ -25,161 +34,521 @@ var (
 	COMMIT_ID     string
 )
 
-type InputParams struct {
-	Traceparent             string
-	OtelResourceAttrs       map[string]string
-	OtelServiceName         string
-	OtelExporterEndpoint    string
-	OtelExporterOtlpHeaders map[string]string
-	StartedAt               string
-	CreatedAt               string
-	JobStatus               string
-	JobName                 string
+func initTracer(params InputParams, res *resource.Resource) (func(), error) {
+	cfg := telemetry.Config{
+		Exporters:   params.Exporters,
+		Resource:    res,
+		Synchronous: params.EphemeralRunner,
+		Sampler:     params.Sampler,
+	}
+	if params.EphemeralRunner {
+		cfg.FlushTimeout = ephemeralFlushTimeout
+	}
+	if params.Debug {
+		cfg.OnExport = logExport
+	}
+	exporter, err := telemetry.NewExporter(context.Background(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize exporter: %w", err)
+	}
+
+	otel.SetTracerProvider(exporter.TracerProvider())
+
+	return func() {
+		// Ephemeral runners may be torn down as soon as the action returns, so
+		// spans are exported as they end, each export and shutdown only waiting
+		// a bounded time.
+		ctx := context.Background()
+		if params.EphemeralRunner {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, ephemeralFlushTimeout)
+			defer cancel()
+		}
+
+		if err := exporter.Shutdown(ctx); err != nil {
+			errorf("failed to shut down tracer provider: %v", err)
+		}
+
+		for _, delivery := range exporter.Deliveries() {
+			if delivery.Err != nil {
+				errorf("failed to confirm span delivery to %s: %v", delivery.Endpoint, delivery.Err)
+			} else {
+				githubactions.Infof("Confirmed delivery of %d span(s) to %s", delivery.Spans, delivery.Endpoint)
+			}
+		}
+	}, nil
 }
 
-func parseInputParams() InputParams {
-	return InputParams{
-		Traceparent:             githubactions.GetInput("traceparent"),
-		OtelResourceAttrs:       parseKeyValuePairs(githubactions.GetInput("otel-resource-attributes")),
-		OtelServiceName:         githubactions.GetInput("otel-service-name"),
-		OtelExporterEndpoint:    githubactions.GetInput("otel-exporter-otlp-endpoint"),
-		OtelExporterOtlpHeaders: parseKeyValuePairs(githubactions.GetInput("otel-exporter-otlp-headers")),
-		StartedAt:               githubactions.GetInput("started-at"),
-		CreatedAt:               githubactions.GetInput("created-at"),
-		JobStatus:               githubactions.GetInput("job-status"),
-		JobName:                 githubactions.GetInput("job-name"),
+func main() {
+	importUnderscoreInputs()
+
+	// The post step only has work to do when the main step started the job.
+	isPost := isPostStep()
+	if isPost && os.Getenv("STATE_"+statePhase) != phaseStart {
+		return
+	}
+	if !isPost {
+		saveState(stateIsPost, "true")
 	}
-}
 
-func parseKeyValuePairs(input string) map[string]string {
-	pairs := make(map[string]string)
-	for _, pair := range strings.Split(input, ",") {
-		kv := strings.SplitN(pair, "=", 2)
-		if len(kv) == 2 {
-			pairs[kv[0]] = kv[1]
+	githubactions.Infof("Starting %s version: %s (%s) commit: %s", actionName, BUILD_VERSION, BUILD_DATE, COMMIT_ID)
+
+	// run returns once its deferred shutdowns have exported the spans and
+	// metrics already recorded, so an error aborts the action only then.
+	if err := run(isPost); err != nil {
+		if failOnError {
+			githubactions.Fatalf("%v", err)
 		}
+		githubactions.Warningf("%v", err)
 	}
-	return pairs
+	exitOnReportedErrors()
 }
 
-func initTracer(endpoint, serviceName string, attrs, headers map[string]string) func() {
-	resourceAttributes := make([]attribute.KeyValue, 0, len(attrs)+1)
-	for k, v := range attrs {
-		resourceAttributes = append(resourceAttributes, attribute.String(k, v))
+func run(isPost bool) error {
+	var err error
+	if failOnError, err = parseBoolInput("fail-on-error"); err != nil {
+		return err
 	}
-	resourceAttributes = append(resourceAttributes, attribute.String(string(semconv.ServiceNameKey), serviceName))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		errorf("OpenTelemetry error: %v", err)
+	}))
 
-	res := resource.NewWithAttributes(semconv.SchemaURL, resourceAttributes...)
+	params, err := parseInputParams()
+	if err != nil {
+		return err
+	}
+	params.Provider.ApplyDefaults(&params)
+	switch {
+	case isPost:
+		params.StartedAt = os.Getenv("STATE_" + stateStartedAt)
+	case params.Phase == phaseStart:
+		return startJob(params)
+	case params.Phase != "":
+		return fmt.Errorf("invalid phase: %q, expected %s", params.Phase, phaseStart)
+	}
+	if isAct() {
+		applyActDefaults(&params)
+	}
 
-	clientOptions := []otlptracegrpc.Option{
-		otlptracegrpc.WithEndpoint(endpoint),
-		otlptracegrpc.WithHeaders(headers),
+	if params.OIDCAuth {
+		token, err := oidcToken(context.Background(), params.OIDCAudience, params.OIDCTokenExchangeURL)
+		if err != nil {
+			return err
+		}
+		applyOIDCAuth(&params, token)
 	}
 
-	exp, err := otlptracegrpc.New(context.Background(), clientOptions...)
-	if err != nil {
-		githubactions.Fatalf("failed to initialize exporter: %v", err)
+	for i := range params.Exporters {
+		if err := params.Exporters[i].Normalize(); err != nil {
+			return err
+		}
 	}
 
-	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exp),
-		sdktrace.WithResource(res),
-	)
+	if params.Debug, err = debugEnabled(); err != nil {
+		return err
+	}
+	if params.Debug {
+		params.LogSpan = true
+		logConfiguration(params)
+	}
 
-	otel.SetTracerProvider(tracerProvider)
+	if params.VerifyConnectivity {
+		verifyConnectivity(params.Exporters)
+	}
 
-	return func() {
-		if err := tracerProvider.Shutdown(context.Background()); err != nil {
-			githubactions.Errorf("failed to shut down tracer provider: %v", err)
+	// Replayed spans keep the resources they were recorded with.
+	if params.Mode == modeReplay {
+		return replayFiles(params)
+	}
+
+	// Baggage comes first so that otel-resource-attributes overrides it.
+	resourceAttrs := append(telemetry.BaggageAttributes(params.Baggage), params.OtelResourceAttrs...)
+	res := telemetry.NewResourceWithSchemaURL(params.AttributeSchema.SchemaURL(), params.OtelServiceName, resourceAttrs)
+
+	shutdownTracer, err := initTracer(params, res)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracer()
+
+	switch params.Mode {
+	case "", modeJob:
+		return exportJob(params, res)
+	case modeWorkflowRun:
+		if params.Provider.Name() != providerGitHub {
+			return fmt.Errorf("%s mode is only supported on GitHub Actions", modeWorkflowRun)
 		}
+		return exportWorkflowRun(params, res)
+	default:
+		return fmt.Errorf("invalid mode: %q, expected %s, %s or %s", params.Mode, modeJob, modeWorkflowRun, modeReplay)
 	}
 }
 
-func main() {
-	githubactions.Infof("Starting %s version: %s (%s) commit: %s", actionName, BUILD_VERSION, BUILD_DATE, COMMIT_ID)
+// verifyConnectivity reports exporters that cannot be reached before any span
+// is emitted, instead of leaving the batcher to drop the spans at shutdown.
+func verifyConnectivity(exporters []telemetry.ExporterConfig) {
+	for _, exporter := range exporters {
+		ctx, cancel := context.WithTimeout(context.Background(), connectivityTimeout)
+		err := telemetry.CheckConnectivity(ctx, exporter)
+		cancel()
+		if err != nil {
+			errorf("failed to verify connectivity to %s: %v", exporter.Name(), err)
+			continue
+		}
+		githubactions.Infof("Verified connectivity to %s", exporter.Name())
+	}
+}
 
-	params := parseInputParams()
+func exportJob(params InputParams, res *resource.Resource) error {
+	traceparent := resolveTraceparent(params)
 
-	shutdownTracer := initTracer(params.OtelExporterEndpoint, params.OtelServiceName, params.OtelResourceAttrs, params.OtelExporterOtlpHeaders)
-	defer shutdownTracer()
+	// A bad timestamp should not fail the job, so the span is still exported,
+	// without a duration, and records why.
+	var timestampErrors []string
+	startedAtTime, err := telemetry.ParseTimestamp(params.StartedAt)
+	if err != nil {
+		githubactions.Warningf("failed to parse started-at, exporting the job span without a duration: %v", err)
+		timestampErrors = append(timestampErrors, "started-at: "+err.Error())
+		startedAtTime = time.Now()
+	}
+	hasStartedAt := len(timestampErrors) == 0
 
-	parts := strings.Split(params.Traceparent, "-")
-	if len(parts) != 4 {
-		githubactions.Fatalf("invalid traceparent: %v", params.Traceparent)
+	if params.TriggerCommentURL != "" {
+		if err := validateURL(params.TriggerCommentURL); err != nil {
+			return fmt.Errorf("invalid trigger-comment-url: %v", err)
+		}
 	}
 
-	traceID, err := hex.DecodeString(parts[1])
+	spanName := telemetry.DefaultJobSpanName
+	if params.SpanName != "" {
+		spanName, err = telemetry.ExpandSpanName(params.SpanName, spanNameValues(params))
+		if err != nil {
+			return err
+		}
+	}
+	spanKind, err := telemetry.ParseSpanKind(params.SpanKind)
 	if err != nil {
-		githubactions.Fatalf("invalid TraceID: %v", err)
+		return err
 	}
 
-	parentSpanID, err := hex.DecodeString(parts[2])
-	if err != nil {
-		githubactions.Fatalf("invalid SpanID: %v", err)
+	builder := telemetry.NewJobSpanBuilder(spanName, startedAtTime).WithKind(spanKind).
+		WithConclusion(params.JobStatus).
+		WithErrorConclusions(params.ErrorConclusions).
+		WithStatusMapping(params.StatusMapping).
+		WithAttributeSchema(params.AttributeSchema)
+	// GitHub only serves the log and billable time of a completed job, and the
+	// job the action runs in is still in progress.
+	if params.ExportLogs {
+		githubactions.Warningf("Skipping export-logs: the log of a job is only available once it has completed, use %s mode to export it", modeWorkflowRun)
+		params.ExportLogs = false
+	}
+	if params.ExportBillableTime {
+		githubactions.Warningf("Skipping export-billable-time: the billable time of a job is only reported once it has completed, use %s mode to export it", modeWorkflowRun)
+		params.ExportBillableTime = false
 	}
 
-	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
-		TraceID:    trace.TraceID(traceID),
-		SpanID:     trace.SpanID(parentSpanID),
-		TraceFlags: trace.FlagsSampled,
-		Remote:     true,
-	})
+	var current *currentJob
+	if exportsJobDetails(params) {
+		current = fetchCurrentJobDetails(context.Background(), params)
+	}
+	if params.StatusMessage != "" {
+		message, err := jobStatusMessage(params.StatusMessage, spanNameValues(params), current.failedStep())
+		if err != nil {
+			return err
+		}
+		builder.WithStatusMessage(message)
+	}
 
-	ctx := trace.ContextWithRemoteSpanContext(context.Background(), spanContext)
+	if traceparent == "" {
+		// Without a traceparent the job span is a root. Within a CI run the
+		// trace id is derived from the run, so all jobs of the run share a trace.
+		if traceID, ok := params.Provider.TraceID(); ok {
+			builder.WithRootTraceID(traceID)
+		}
+	} else {
+		spanContext, err := telemetry.ParseTraceContext(traceparent, params.Tracestate)
+		if err != nil {
+			return err
+		}
+		if params.Debug {
+			logTraceparent(traceparent, spanContext)
+		}
+		relationship, err := telemetry.ParseRelationship(params.ParentRelationship)
+		if err != nil {
+			return err
+		}
+		builder.WithParent(spanContext, relationship)
+	}
 
-	startedAtTime, err := time.Parse(time.RFC3339, params.StartedAt)
-	if err != nil {
-		githubactions.Fatalf("failed to parse started-at time: %v", err)
+	if params.IssueTraceparent != "" {
+		issueSpanContext, err := telemetry.ParseTraceparent(params.IssueTraceparent)
+		if err != nil {
+			return fmt.Errorf("invalid issue-traceparent: %v", err)
+		}
+
+		var linkAttributes []attribute.KeyValue
+		if params.TriggerCommentURL != "" {
+			linkAttributes = append(linkAttributes, attribute.String("ci.github.trigger.comment_url", params.TriggerCommentURL))
+		}
+		builder.WithLinks(trace.Link{SpanContext: issueSpanContext, Attributes: linkAttributes})
 	}
 
-	tracer := otel.Tracer(actionName)
-	_, span := tracer.Start(ctx, "Job telemetry", trace.WithTimestamp(startedAtTime))
+	builder.WithLinks(params.SpanLinks...)
 
-	attributes := []attribute.KeyValue{
-		attribute.String("ci.github.workflow.job.conclusion", params.JobStatus),
+	for _, event := range params.SpanEvents {
+		if hasStartedAt && event.Timestamp.Before(startedAtTime) {
+			githubactions.Warningf("span event %q at %s is before the job started", event.Name, event.Timestamp.Format(time.RFC3339))
+		}
+	}
+	builder.WithEvents(params.SpanEvents...)
+
+	// Baseline attributes come first so that any attribute set for the job,
+	// including otel-resource-attributes, overrides them.
+	builder.WithAttributes(params.BaselineAttrs...)
+	builder.WithAttributes(telemetry.BaggageAttributes(params.Baggage)...)
+	if params.AutoDetect {
+		builder.WithAttributes(params.Provider.Attributes()...)
 	}
+	builder.WithAttributes(attribute.String("ci.github.workflow.job.conclusion", params.JobStatus))
 
-	var spanStatus codes.Code
-	var spanMessage string
-	switch params.JobStatus {
-	case "success":
-		spanStatus = codes.Ok
-		spanMessage = "Job completed successfully"
-	case "failure":
-		spanStatus = codes.Error
-		spanMessage = "Job failed"
-	default:
-		spanStatus = codes.Unset
-		spanMessage = "Job status unknown"
+	if params.TriggerCommentURL != "" {
+		builder.WithAttributes(attribute.String("ci.github.trigger.comment_url", params.TriggerCommentURL))
 	}
-	span.SetStatus(spanStatus, spanMessage)
 
+	var queueLatency *time.Duration
+	var createdAtTime time.Time
 	if params.CreatedAt != "" {
-		createdAtTime, err := time.Parse(time.RFC3339, params.CreatedAt)
+		createdAtTime, err = telemetry.ParseTimestamp(params.CreatedAt)
+		switch {
+		case err != nil:
+			githubactions.Warningf("failed to parse created-at, exporting the job span without a queue latency: %v", err)
+			timestampErrors = append(timestampErrors, "created-at: "+err.Error())
+			createdAtTime = time.Time{}
+		case hasStartedAt:
+			latency := startedAtTime.Sub(createdAtTime)
+			queueLatency = &latency
+			builder.WithAttributes(attribute.Int64("ci.github.workflow.job.start_latency_ms", latency.Milliseconds()))
+		default:
+			createdAtTime = time.Time{}
+		}
+	}
+	if len(timestampErrors) > 0 {
+		builder.WithAttributes(attribute.String("telemetry.timestamp.parse_error", strings.Join(timestampErrors, "; ")))
+	}
+
+	if params.JobName != "" {
+		builder.WithAttributes(attribute.String("ci.github.workflow.job.name", params.JobName))
+	}
+
+	matrix, err := telemetry.ParseMatrix(params.Matrix)
+	if err != nil {
+		return err
+	}
+	builder.WithMatrix(matrix)
+
+	builder.WithAttributes(params.Provider.RunAttributes()...)
+	if params.DeterministicSpanID {
+		// Matrix legs share the job name, so the matrix is part of the job's
+		// identity.
+		jobName := spanNameValues(params)["job"]
+		if len(matrix) > 0 {
+			jobName += " (" + matrix.String() + ")"
+		}
+		if spanID, ok := params.Provider.JobSpanID(jobName); ok {
+			builder.WithSpanID(spanID)
+		} else {
+			githubactions.Warningf("deterministic-span-id requires a CI run, using a random span id")
+		}
+	}
+
+	if params.BuildTool != "" {
+		if !tokenPattern.MatchString(params.BuildTool) {
+			return fmt.Errorf("invalid build-tool: %q", params.BuildTool)
+		}
+		builder.WithAttributes(attribute.String("ci.build.tool", params.BuildTool))
+	}
+
+	if params.BuildToolVersion != "" {
+		if !tokenPattern.MatchString(params.BuildToolVersion) {
+			return fmt.Errorf("invalid build-tool-version: %q", params.BuildToolVersion)
+		}
+		builder.WithAttributes(attribute.String("ci.build.tool.version", params.BuildToolVersion))
+	}
+
+	if params.RetentionTier != "" {
+		if !tokenPattern.MatchString(params.RetentionTier) {
+			return fmt.Errorf("invalid retention-tier: %q", params.RetentionTier)
+		}
+		builder.WithAttributes(attribute.String("ci.telemetry.retention_tier", params.RetentionTier))
+	}
+
+	if params.ArtifactName != "" {
+		builder.WithAttributes(attribute.String("ci.artifact.name", params.ArtifactName))
+	}
+
+	var artifactSize, artifactCount *int64
+	if params.ArtifactSizeBytes != "" {
+		size, err := strconv.ParseInt(params.ArtifactSizeBytes, 10, 64)
+		if err != nil || size < 0 {
+			return fmt.Errorf("invalid artifact-size-bytes: %q", params.ArtifactSizeBytes)
+		}
+		artifactSize = &size
+		builder.WithAttributes(attribute.Int64("ci.artifact.size_bytes", size))
+	}
+
+	if params.ArtifactCount != "" {
+		count, err := strconv.ParseInt(params.ArtifactCount, 10, 64)
+		if err != nil || count < 0 {
+			return fmt.Errorf("invalid artifact-count: %q", params.ArtifactCount)
+		}
+		artifactCount = &count
+		builder.WithAttributes(attribute.Int64("ci.artifact.count", count))
+	}
+
+	// actions/cache sets cache-hit to false for a restore-key match and may
+	// leave it empty on a miss, so an empty value is treated as unknown.
+	var cacheHit *bool
+	if params.CacheHit != "" {
+		hit, err := strconv.ParseBool(params.CacheHit)
 		if err != nil {
-			githubactions.Fatalf("failed to parse created-at time: %v", err)
+			return fmt.Errorf("invalid cache-hit: %q", params.CacheHit)
 		}
+		cacheHit = &hit
+		builder.WithAttributes(attribute.Bool("ci.github.workflow.job.cache.hit", hit))
+	}
 
-		latency := startedAtTime.Sub(createdAtTime)
-		attributes = append(attributes, attribute.Int64("ci.github.workflow.job.start_latency_ms", latency.Milliseconds()))
+	var cacheSize *int64
+	if params.CacheSizeBytes != "" {
+		size, err := strconv.ParseInt(params.CacheSizeBytes, 10, 64)
+		if err != nil || size < 0 {
+			return fmt.Errorf("invalid cache-size-bytes: %q", params.CacheSizeBytes)
+		}
+		cacheSize = &size
+		builder.WithAttributes(attribute.Int64("ci.github.workflow.job.cache.size_bytes", size))
 	}
 
-	if params.JobName != "" {
+	if params.ArtifactDigest != "" {
+		builder.WithAttributes(attribute.String("ci.artifact.digest", params.ArtifactDigest))
+	}
+
+	if params.GitHubRateLimitRemaining != "" {
+		remaining, err := strconv.ParseInt(params.GitHubRateLimitRemaining, 10, 64)
+		if err != nil || remaining < 0 {
+			return fmt.Errorf("invalid github-rate-limit-remaining: %q", params.GitHubRateLimitRemaining)
+		}
+		builder.WithAttributes(attribute.Int64("ci.github.rate_limit.remaining", remaining))
+	}
+
+	if params.GitHubRateLimitReset != "" {
+		reset, err := parseRateLimitReset(params.GitHubRateLimitReset)
+		if err != nil {
+			return fmt.Errorf("invalid github-rate-limit-reset: %v", err)
+		}
+		builder.WithAttributes(attribute.Int64("ci.github.rate_limit.reset", reset))
+	}
+
+	var resourceUsage *telemetry.ResourceUsage
+	if params.ExportResourceUsage {
+		dir := os.Getenv("GITHUB_WORKSPACE")
+		if dir == "" {
+			dir = "."
+		}
+		usage, err := telemetry.ReadResourceUsage(dir, jobStartCPUSample())
 		if err != nil {
-			githubactions.Fatalf("failed to parse job-name: %v", err)
+			githubactions.Warningf("failed to read runner resource usage: %v", err)
 		}
-		attributes = append(attributes, attribute.String("ci.github.workflow.job.name", params.JobName))
+		resourceUsage = &usage
+		builder.WithAttributes(usage.Attributes()...)
 	}
 
+	// The end time comes from the runner clock, the start time from GitHub.
 	endTime := time.Now()
-	duration := endTime.Sub(startedAtTime)
-	attributes = append(attributes, attribute.Int64("ci.github.workflow.job.duration_ms", duration.Milliseconds()))
+	var duration *time.Duration
+	if !hasStartedAt {
+		endTime = startedAtTime
+	} else if params.CorrectClockSkew {
+		if skew, ok := measureClockSkew(context.Background(), params); ok {
+			githubactions.Infof("Correcting runner clock skew of %s", skew)
+			endTime = endTime.Add(skew)
+			builder.WithAttributes(attribute.Int64("ci.github.workflow.job.clock_skew_ms", skew.Milliseconds()))
+		}
+	}
+	if endTime.Before(startedAtTime) {
+		githubactions.Warningf("Job end time %s is before started-at %s, the runner clock may be skewed; clamping the duration to 0", endTime.Format(time.RFC3339), startedAtTime.Format(time.RFC3339))
+		endTime = startedAtTime
+	}
+	if hasStartedAt {
+		jobDuration := endTime.Sub(startedAtTime)
+		duration = &jobDuration
+		builder.WithAttributes(attribute.Int64("ci.github.workflow.job.duration_ms", jobDuration.Milliseconds()))
+	}
 
-	for k, v := range params.OtelResourceAttrs {
-		attributes = append(attributes, attribute.String(k, v))
+	builder.WithAttributes(params.OtelResourceAttrs...)
+
+	tracer := otel.Tracer(actionName)
+	ctx := baggage.ContextWithBaggage(context.Background(), params.Baggage)
+	jobCtx, span := builder.Start(ctx, tracer)
+
+	if params.LogSpan {
+		logSpan(builder.Name(), span.SpanContext(), builder.Attributes())
 	}
 
-	span.SetAttributes(attributes...)
+	if params.ExportQueueSpan {
+		if createdAtTime.IsZero() {
+			githubactions.Warningf("export-queue-span requires created-at")
+		} else {
+			telemetry.ExportQueueSpan(jobCtx, tracer, createdAtTime, startedAtTime, runnerAttributes(os.Getenv("RUNNER_NAME"), params.RunnerLabels)...)
+		}
+	}
+
+	if current != nil {
+		exportCurrentJobDetails(jobCtx, tracer, params, res, span, current, endTime)
+	}
 
 	span.End(trace.WithTimestamp(endTime))
+	traceURL := setTraceOutputs(span.SpanContext(), params.TraceURL)
+	if params.JobSummary {
+		addStepSummary(jobSummary{
+			Name:         builder.Name(),
+			Conclusion:   params.JobStatus,
+			Duration:     duration,
+			QueueLatency: queueLatency,
+			TraceID:      span.SpanContext().TraceID(),
+			TraceURL:     traceURL,
+			Attributes:   builder.Attributes(),
+		}.Markdown())
+	} else {
+		addStepSummary("Exported trace " + traceLink(span.SpanContext().TraceID(), traceURL))
+	}
+
+	if traceparent == "" {
+		generated := telemetry.FormatTraceparent(span.SpanContext())
+		setOutput("traceparent", generated)
+		addStepSummary(fmt.Sprintf("No traceparent was provided, generated `%s`", generated))
+		githubactions.Infof("Generated traceparent: %s", generated)
+	}
+
+	if params.ExportMetrics {
+		metrics := telemetry.JobMetrics{
+			Duration:          duration,
+			QueueLatency:      queueLatency,
+			CacheHit:          cacheHit,
+			CacheSizeBytes:    cacheSize,
+			ArtifactCount:     artifactCount,
+			ArtifactSizeBytes: artifactSize,
+			ResourceUsage:     resourceUsage,
+			Conclusion:        params.JobStatus,
+			JobName:           params.JobName,
+		}
+		for _, exporter := range params.Exporters {
+			if exporter.Type == telemetry.ExporterTypeFile {
+				githubactions.Warningf("Skipping metrics: the file exporter only supports traces")
+				continue
+			}
+			if err := telemetry.RecordJobMetrics(context.Background(), exporter, res, metrics); err != nil {
+				errorf("failed to export metrics to %s: %v", exporter.Name(), err)
+			}
+		}
+	}
+	return nil
 }
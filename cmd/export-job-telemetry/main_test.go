@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+)
+
+// collector is an OTLP/gRPC trace collector recording the spans it receives.
+type collector struct {
+	collectortrace.UnimplementedTraceServiceServer
+
+	mu    sync.Mutex
+	spans []*tracepb.Span
+}
+
+func (c *collector) Export(_ context.Context, req *collectortrace.ExportTraceServiceRequest) (*collectortrace.ExportTraceServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			c.spans = append(c.spans, ss.GetSpans()...)
+		}
+	}
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+func (c *collector) Spans() []*tracepb.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*tracepb.Span(nil), c.spans...)
+}
+
+// startCollector serves a collector on a free local port and returns its
+// endpoint.
+func startCollector(t *testing.T) (*collector, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &collector{}
+	server := grpc.NewServer()
+	collectortrace.RegisterTraceServiceServer(server, c)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return c, listener.Addr().String()
+}
+
+// setInputs sets the environment the runner passes to the action under act,
+// exporting insecurely to endpoint.
+func setInputs(t *testing.T, endpoint string, inputs map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, file := range []string{"GITHUB_OUTPUT", "GITHUB_STATE", "GITHUB_STEP_SUMMARY"} {
+		t.Setenv(file, filepath.Join(dir, strings.ToLower(file)))
+	}
+	t.Setenv("ACT", "true")
+	// act sets neither, and the test may itself run on a runner that does.
+	for _, name := range []string{"RUNNER_ENVIRONMENT", "ACTIONS_RUNTIME_TOKEN"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Setenv("INPUT_OTEL-EXPORTER-OTLP-ENDPOINT", endpoint)
+	t.Setenv("INPUT_OTEL-EXPORTER-OTLP-INSECURE", "true")
+	t.Setenv("INPUT_OTEL-SERVICE-NAME", "test")
+	t.Setenv("INPUT_STARTED-AT", "2024-01-01T00:00:00Z")
+	t.Setenv("INPUT_JOB-STATUS", "success")
+	t.Setenv("INPUT_TRACEPARENT", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	for name, value := range inputs {
+		t.Setenv("INPUT_"+strings.ToUpper(name), value)
+	}
+}
+
+// testRunJobs is the response of the jobs of run 1 of octo/repo, with the
+// build job running on runner-1.
+const testRunJobs = `{"total_count": 1, "jobs": [{
+	"id": 7, "run_id": 1, "run_attempt": 1, "name": "build",
+	"status": "in_progress", "runner_name": "runner-1",
+	"created_at": "2023-12-31T23:59:00Z", "started_at": "2024-01-01T00:00:00Z",
+	"steps": [{
+		"name": "Checkout", "number": 1, "status": "completed", "conclusion": "success",
+		"started_at": "2024-01-01T00:00:01Z", "completed_at": "2024-01-01T00:00:05Z"
+	}]
+}]}`
+
+// startGitHub serves the GitHub API of run 1 of octo/repo and sets the
+// environment of its build job. It returns the inputs to fetch the job with.
+func startGitHub(t *testing.T) map[string]string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/repo/actions/runs/1/attempts/1/jobs":
+			fmt.Fprint(w, testRunJobs)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("GITHUB_REPOSITORY", "octo/repo")
+	t.Setenv("GITHUB_RUN_ID", "1")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "1")
+	t.Setenv("RUNNER_NAME", "runner-1")
+	return map[string]string{"github-token": "token", "github-api-url": server.URL}
+}
+
+// findSpan returns the span named name, or the job span when name is empty.
+func findSpan(spans []*tracepb.Span, name string) *tracepb.Span {
+	for _, span := range spans {
+		if name == "" && hex.EncodeToString(span.GetParentSpanId()) == "b7ad6b7169203331" || name != "" && span.GetName() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func spanAttributes(span *tracepb.Span, key string) []string {
+	var values []string
+	for _, kv := range span.GetAttributes() {
+		if kv.GetKey() == key {
+			values = append(values, kv.GetValue().GetStringValue())
+		}
+	}
+	return values
+}
+
+func TestBaselineAttributePrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs map[string]string
+		github bool
+		span   string
+		key    string
+		want   string
+	}{
+		{
+			name:   "baseline only",
+			inputs: map[string]string{"baseline-attributes": "team=platform"},
+			key:    "team",
+			want:   "platform",
+		},
+		{
+			name:   "otel-resource-attributes override baseline",
+			inputs: map[string]string{"baseline-attributes": "team=platform", "otel-resource-attributes": "team=payments"},
+			key:    "team",
+			want:   "payments",
+		},
+		{
+			name:   "job attributes override baseline",
+			inputs: map[string]string{"baseline-attributes": "ci.github.workflow.job.conclusion=unknown"},
+			key:    "ci.github.workflow.job.conclusion",
+			want:   "success",
+		},
+		{
+			name:   "baseline on step spans",
+			inputs: map[string]string{"baseline-attributes": "team=platform", "export-steps": "true"},
+			github: true,
+			span:   "Checkout",
+			key:    "team",
+			want:   "platform",
+		},
+		{
+			name:   "step attributes override baseline",
+			inputs: map[string]string{"baseline-attributes": "ci.github.workflow.job.step.conclusion=unknown", "export-steps": "true"},
+			github: true,
+			span:   "Checkout",
+			key:    "ci.github.workflow.job.step.conclusion",
+			want:   "success",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, endpoint := startCollector(t)
+			inputs := tt.inputs
+			if tt.github {
+				inputs = telemetry.MergeKeyValuePairs(inputs, startGitHub(t))
+			}
+			setInputs(t, endpoint, inputs)
+
+			main()
+
+			span := findSpan(c.Spans(), tt.span)
+			if span == nil {
+				t.Fatalf("exported no %q span", tt.span)
+			}
+			if got := spanAttributes(span, tt.key); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("%s = %q, want [%q]", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttributeSchemaNames(t *testing.T) {
+	for _, schema := range []telemetry.AttributeSchema{telemetry.AttributeSchemaLegacy, telemetry.AttributeSchemaCICD, telemetry.AttributeSchemaBoth} {
+		t.Run(string(schema), func(t *testing.T) {
+			c, endpoint := startCollector(t)
+			inputs := telemetry.MergeKeyValuePairs(map[string]string{
+				"attribute-schema":    string(schema),
+				"baseline-attributes": "ci.github.workflow.name=baseline",
+				"job-name":            "Build",
+				"export-steps":        "true",
+			}, startGitHub(t))
+			setInputs(t, endpoint, inputs)
+			t.Setenv("GITHUB_WORKFLOW", "CI")
+			t.Setenv("GITHUB_JOB", "build")
+			t.Setenv("GITHUB_REF_NAME", "main")
+
+			main()
+
+			for _, name := range []string{"", "Checkout"} {
+				span := findSpan(c.Spans(), name)
+				if span == nil {
+					t.Fatalf("exported no %q span", name)
+				}
+				keys := make(map[string]int)
+				for _, kv := range span.GetAttributes() {
+					keys[kv.GetKey()]++
+				}
+				for key, n := range keys {
+					if n > 1 {
+						t.Errorf("%q span sets %s %d times", name, key, n)
+					}
+					if schema == telemetry.AttributeSchemaLegacy && (strings.HasPrefix(key, "cicd.") || strings.HasPrefix(key, "vcs.")) {
+						t.Errorf("%q span sets %s with the legacy schema", name, key)
+					}
+				}
+				_, legacy := keys["ci.github.workflow.name"]
+				_, cicd := keys["cicd.pipeline.name"]
+				if legacy != (schema != telemetry.AttributeSchemaCICD) || cicd != (schema != telemetry.AttributeSchemaLegacy) {
+					t.Errorf("%q span sets ci.github.workflow.name %t and cicd.pipeline.name %t with the %s schema", name, legacy, cicd, schema)
+				}
+			}
+
+			job := findSpan(c.Spans(), "")
+			jobName := "ci.github.workflow.job.name"
+			if schema == telemetry.AttributeSchemaCICD {
+				jobName = "cicd.pipeline.task.name"
+			}
+			if got := spanAttributes(job, jobName); len(got) != 1 || got[0] != "Build" {
+				t.Errorf("%s = %q, want [Build], the job-name input", jobName, got)
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  map[string]string
+		wantErr string
+	}{
+		{name: "invalid bool input", inputs: map[string]string{"export-steps": "maybe"}, wantErr: `invalid export-steps: "maybe"`},
+		{name: "invalid duration input", inputs: map[string]string{"otel-exporter-otlp-retry-max-interval": "soon"}, wantErr: "invalid otel-exporter-otlp-retry-max-interval"},
+		{name: "invalid mode", inputs: map[string]string{"mode": "pipeline"}, wantErr: `invalid mode: "pipeline"`},
+		{name: "invalid job input", inputs: map[string]string{"artifact-count": "-1"}, wantErr: `invalid artifact-count: "-1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, endpoint := startCollector(t)
+			setInputs(t, endpoint, tt.inputs)
+
+			err := run(false)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("run() error = %v, want %q", err, tt.wantErr)
+			}
+			if spans := c.Spans(); len(spans) != 0 {
+				t.Errorf("exported %d span(s) after the error", len(spans))
+			}
+		})
+	}
+}
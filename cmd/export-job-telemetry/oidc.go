@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+const oidcTimeout = 30 * time.Second
+
+// oidcToken requests a GitHub Actions OIDC ID token for audience and, when
+// exchangeURL is set, exchanges it there for an access token using OAuth 2.0
+// token exchange (RFC 8693). The token is masked in the log.
+func oidcToken(ctx context.Context, audience, exchangeURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, oidcTimeout)
+	defer cancel()
+
+	token, err := requestIDToken(ctx, audience)
+	if err != nil {
+		return "", fmt.Errorf("failed to request OIDC token: %w", err)
+	}
+	githubactions.AddMask(token)
+	if exchangeURL == "" {
+		return token, nil
+	}
+
+	token, err = exchangeToken(ctx, exchangeURL, token)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange OIDC token at %s: %w", exchangeURL, err)
+	}
+	githubactions.AddMask(token)
+	return token, nil
+}
+
+func requestIDToken(ctx context.Context, audience string) (string, error) {
+	requestURL, requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL"), os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL == "" || requestToken == "" {
+		return "", errors.New("ACTIONS_ID_TOKEN_REQUEST_URL is not set, the job needs the id-token: write permission")
+	}
+
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	if audience != "" {
+		query := u.Query()
+		query.Set("audience", audience)
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+	req.Header.Set("Accept", "application/json")
+
+	var result struct {
+		Value string `json:"value"`
+	}
+	if err := doJSON(req, &result); err != nil {
+		return "", err
+	}
+	if result.Value == "" {
+		return "", errors.New("empty token in response")
+	}
+	return result.Value, nil
+}
+
+func exchangeToken(ctx context.Context, exchangeURL, idToken string) (string, error) {
+	form := url.Values{
+		"grant_type":         {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_token":      {idToken},
+		"subject_token_type": {"urn:ietf:params:oauth:token-type:id_token"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, exchangeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(req, &result); err != nil {
+		return "", err
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("no access_token in response")
+	}
+	return result.AccessToken, nil
+}
+
+func doJSON(req *http.Request, v any) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// applyOIDCAuth sets the token as the Bearer authorization header of every
+// OTLP exporter, replacing any configured one.
+func applyOIDCAuth(params *InputParams, token string) {
+	for i := range params.Exporters {
+		headers := make(map[string]string, len(params.Exporters[i].Headers)+1)
+		for k, v := range params.Exporters[i].Headers {
+			if !strings.EqualFold(k, "Authorization") {
+				headers[k] = v
+			}
+		}
+		headers["Authorization"] = "Bearer " + token
+		params.Exporters[i].Headers = headers
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+)
+
+// newTestOIDCServers serves the GitHub OIDC token endpoint with tokenHandler
+// and a token exchange endpoint with exchangeHandler, returning the exchange
+// URL.
+func newTestOIDCServers(t *testing.T, tokenHandler, exchangeHandler http.HandlerFunc) string {
+	t.Helper()
+	tokenServer := httptest.NewServer(tokenHandler)
+	t.Cleanup(tokenServer.Close)
+	exchangeServer := httptest.NewServer(exchangeHandler)
+	t.Cleanup(exchangeServer.Close)
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", tokenServer.URL+"/token?api-version=2.0")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+	return exchangeServer.URL + "/oauth/token"
+}
+
+func TestOIDCToken(t *testing.T) {
+	exchangeURL := newTestOIDCServers(t,
+		func(w http.ResponseWriter, r *http.Request) {
+			if got := r.Header.Get("Authorization"); got != "Bearer request-token" {
+				t.Errorf("Authorization = %q, want Bearer request-token", got)
+			}
+			if got := r.URL.Query().Get("api-version"); got != "2.0" {
+				t.Errorf("api-version = %q, want the query of the request URL kept", got)
+			}
+			if got := r.URL.Query().Get("audience"); got != "https://collector.example.com" {
+				t.Errorf("audience = %q, want https://collector.example.com", got)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"count":1,"value":"id-token"}`))
+		},
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				t.Errorf("method = %s, want POST", r.Method)
+			}
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]string{
+				"grant_type":         "urn:ietf:params:oauth:grant-type:token-exchange",
+				"subject_token":      "id-token",
+				"subject_token_type": "urn:ietf:params:oauth:token-type:id_token",
+			}
+			for key, value := range want {
+				if got := r.PostForm.Get(key); got != value {
+					t.Errorf("%s = %q, want %q", key, got, value)
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"access-token","token_type":"Bearer","expires_in":300}`))
+		},
+	)
+
+	token, err := oidcToken(context.Background(), "https://collector.example.com", "")
+	if err != nil {
+		t.Fatalf("oidcToken() error: %v", err)
+	}
+	if token != "id-token" {
+		t.Errorf("oidcToken() = %q, want the ID token without an exchange URL", token)
+	}
+
+	token, err = oidcToken(context.Background(), "https://collector.example.com", exchangeURL)
+	if err != nil {
+		t.Fatalf("oidcToken() error: %v", err)
+	}
+	if token != "access-token" {
+		t.Errorf("oidcToken() = %q, want the exchanged access token", token)
+	}
+}
+
+func TestOIDCTokenErrors(t *testing.T) {
+	ok := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+	failing := func(status int) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, http.StatusText(status), status)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		token    http.HandlerFunc
+		exchange http.HandlerFunc
+		wantErr  string
+	}{
+		{name: "token request forbidden", token: failing(http.StatusForbidden), wantErr: "failed to request OIDC token: unexpected status 403 Forbidden"},
+		{name: "token request server error", token: failing(http.StatusInternalServerError), wantErr: "unexpected status 500"},
+		{name: "token malformed JSON", token: ok(`{"value":`), wantErr: "failed to request OIDC token: invalid response"},
+		{name: "token not JSON", token: ok(`<html>sign in</html>`), wantErr: "failed to request OIDC token: invalid response"},
+		{name: "empty token", token: ok(`{"value":""}`), wantErr: "empty token in response"},
+		{name: "exchange unauthorized", token: ok(`{"value":"id-token"}`), exchange: failing(http.StatusUnauthorized), wantErr: "failed to exchange OIDC token at"},
+		{name: "exchange malformed JSON", token: ok(`{"value":"id-token"}`), exchange: ok(`{"access_token":`), wantErr: "invalid response"},
+		{name: "exchange without access token", token: ok(`{"value":"id-token"}`), exchange: ok(`{"error":"invalid_grant"}`), wantErr: "no access_token in response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchange := tt.exchange
+			if exchange == nil {
+				exchange = func(http.ResponseWriter, *http.Request) {
+					t.Error("token exchanged after the token request failed")
+				}
+			}
+			exchangeURL := newTestOIDCServers(t, tt.token, exchange)
+			if tt.exchange == nil {
+				exchangeURL = ""
+			}
+
+			_, err := oidcToken(context.Background(), "", exchangeURL)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("oidcToken() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDCTokenWithoutPermission(t *testing.T) {
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "")
+
+	_, err := oidcToken(context.Background(), "", "")
+	if err == nil || !strings.Contains(err.Error(), "id-token: write") {
+		t.Errorf("oidcToken() error = %v, want a hint at the id-token permission", err)
+	}
+}
+
+func TestApplyOIDCAuth(t *testing.T) {
+	params := InputParams{Exporters: []telemetry.ExporterConfig{
+		{Endpoint: "collector:4317", Headers: map[string]string{"authorization": "Basic old", "X-Tenant": "ci"}},
+		{Endpoint: "backup:4317"},
+	}}
+
+	applyOIDCAuth(&params, "token")
+
+	for _, exporter := range params.Exporters {
+		if got := exporter.Headers["Authorization"]; got != "Bearer token" {
+			t.Errorf("%s Authorization = %q, want Bearer token", exporter.Endpoint, got)
+		}
+		if _, ok := exporter.Headers["authorization"]; ok {
+			t.Errorf("%s kept the configured authorization header", exporter.Endpoint)
+		}
+	}
+	if got := params.Exporters[0].Headers["X-Tenant"]; got != "ci" {
+		t.Errorf("X-Tenant = %q, want the other headers kept", got)
+	}
+}
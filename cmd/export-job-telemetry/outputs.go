@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setTraceOutputs sets the trace-id, span-id and, when traceURL is set,
+// trace-url outputs for spanContext. It returns the expanded trace URL, which
+// is empty when traceURL is not set or invalid.
+func setTraceOutputs(spanContext trace.SpanContext, traceURL string) string {
+	setOutput("trace-id", spanContext.TraceID().String())
+	setOutput("span-id", spanContext.SpanID().String())
+	if traceURL == "" {
+		return ""
+	}
+
+	url, err := telemetry.TraceURL(traceURL, spanContext)
+	if err != nil {
+		errorf("%v", err)
+		return ""
+	}
+	setOutput("trace-url", url)
+	githubactions.Infof("Trace URL: %s", url)
+	return url
+}
+
+// traceLink formats the trace id as Markdown, linked to url when it is set.
+func traceLink(traceID trace.TraceID, url string) string {
+	if url == "" {
+		return fmt.Sprintf("`%s`", traceID)
+	}
+	return fmt.Sprintf("[`%s`](%s)", traceID, url)
+}
+
+// The file commands of GitHub Actions panic when their file is not set, as it
+// is not outside GitHub Actions with another ci-provider, so they are skipped.
+
+func setOutput(name, value string) {
+	if os.Getenv("GITHUB_OUTPUT") != "" {
+		githubactions.SetOutput(name, value)
+		recordStepOutput(name, value)
+	}
+}
+
+func saveState(name, value string) {
+	if os.Getenv("GITHUB_STATE") != "" {
+		githubactions.SaveState(name, value)
+	}
+}
+
+func addStepSummary(markdown string) {
+	if os.Getenv("GITHUB_STEP_SUMMARY") != "" {
+		githubactions.AddStepSummary(markdown)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+)
+
+const (
+	phaseStart = "start"
+
+	// The runner passes state saved by the main step to the post step as
+	// STATE_<name> environment variables.
+	stateIsPost    = "isPost"
+	statePhase     = "phase"
+	stateStartedAt = "started-at"
+	stateCPUSample = "cpu-sample"
+)
+
+// isPostStep reports whether the action runs as its post step. Every main step
+// saves the isPost state, so that the post step can tell itself apart.
+func isPostStep() bool {
+	return os.Getenv("STATE_"+stateIsPost) == "true"
+}
+
+// startJob records when the job started, for the post step to export the job
+// span once all other steps and their cleanup have finished.
+func startJob(params InputParams) error {
+	if params.Mode != "" && params.Mode != modeJob {
+		return fmt.Errorf("phase %s requires mode %s", phaseStart, modeJob)
+	}
+
+	startedAt := time.Now().UTC()
+	if params.StartedAt != "" {
+		var err error
+		if startedAt, err = telemetry.ParseTimestamp(params.StartedAt); err != nil {
+			return fmt.Errorf("failed to parse started-at: %w", err)
+		}
+	}
+
+	value := startedAt.Format(time.RFC3339Nano)
+	saveState(statePhase, phaseStart)
+	saveState(stateStartedAt, value)
+	if params.ExportResourceUsage {
+		// The post step measures the CPU utilization of the job from this sample.
+		if sample, err := telemetry.ReadCPUSample(); err != nil {
+			githubactions.Warningf("failed to sample runner CPU usage: %v", err)
+		} else {
+			saveState(stateCPUSample, formatCPUSample(sample))
+		}
+	}
+	setOutput("started-at", value)
+	githubactions.Infof("Recorded job start at %s, the job span is exported by the post step", value)
+	return nil
+}
+
+// formatCPUSample formats sample as saved state, its CPU time in nanoseconds
+// followed by the time it was taken.
+func formatCPUSample(sample telemetry.CPUSample) string {
+	return strconv.FormatInt(int64(sample.Usage), 10) + " " + sample.Time.Format(time.RFC3339Nano)
+}
+
+// jobStartCPUSample returns the CPU sample saved at the start of the job, or
+// the zero sample when there is none.
+func jobStartCPUSample() telemetry.CPUSample {
+	usage, at, ok := strings.Cut(os.Getenv("STATE_"+stateCPUSample), " ")
+	if !ok {
+		return telemetry.CPUSample{}
+	}
+	ns, err := strconv.ParseInt(usage, 10, 64)
+	if err != nil {
+		return telemetry.CPUSample{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return telemetry.CPUSample{}
+	}
+	return telemetry.CPUSample{Time: t, Usage: time.Duration(ns)}
+}
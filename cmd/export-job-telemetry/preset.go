@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+)
+
+// applyPreset fills in the endpoint, protocol, insecure flag and headers of the
+// exporter from the preset input, leaving settings that are configured
+// explicitly as they are.
+func applyPreset(exporters []telemetry.ExporterConfig) error {
+	name := strings.TrimSpace(githubactions.GetInput("preset"))
+	if name == "" {
+		return nil
+	}
+	preset, err := telemetry.LookupPreset(name)
+	if err != nil {
+		return err
+	}
+	if len(exporters) != 1 {
+		return fmt.Errorf("preset supports a single endpoint, got %d", len(exporters))
+	}
+
+	apiKey := strings.TrimSpace(githubactions.GetInput("preset-api-key"))
+	if apiKey != "" {
+		githubactions.AddMask(apiKey)
+	}
+	if err := preset.Apply(&exporters[0], apiKey, insecureSet()); err != nil {
+		return err
+	}
+	for _, value := range exporters[0].Headers {
+		if value != "" {
+			githubactions.AddMask(value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	providerGitHub    = "github"
+	providerGitLab    = "gitlab"
+	providerBuildkite = "buildkite"
+)
+
+// Provider reads the job being exported from the environment of a CI system,
+// so that the same job span can be emitted outside GitHub Actions.
+type Provider interface {
+	// Name is the value of the ci-provider input that selects the provider.
+	Name() string
+
+	// ApplyDefaults fills the inputs that are not set from the environment,
+	// e.g. the start time of the job.
+	ApplyDefaults(params *InputParams)
+
+	// SpanNameValues are the placeholders available to the span-name input.
+	SpanNameValues(params InputParams) map[string]string
+
+	// Attributes describe the pipeline, repository and runner of the job.
+	// They are detected unless auto-detect is disabled.
+	Attributes() []attribute.KeyValue
+
+	// RunAttributes identify the pipeline run and are always set.
+	RunAttributes() []attribute.KeyValue
+
+	// TraceID is shared by the jobs of the pipeline run, so that their root
+	// spans end up in one trace.
+	TraceID() (trace.TraceID, bool)
+
+	// JobSpanID derives the span id of the named job from its identity, for
+	// deterministic-span-id.
+	JobSpanID(jobName string) (trace.SpanID, bool)
+}
+
+// ciProvider selects the provider of the ci-provider input, detecting it from
+// the environment when not set.
+func ciProvider() (Provider, error) {
+	name := strings.ToLower(strings.TrimSpace(githubactions.GetInput("ci-provider")))
+	if name == "" {
+		switch {
+		case os.Getenv("GITLAB_CI") == "true":
+			name = providerGitLab
+		case os.Getenv("BUILDKITE") == "true":
+			name = providerBuildkite
+		default:
+			name = providerGitHub
+		}
+	}
+
+	switch name {
+	case providerGitHub:
+		return githubProvider{}, nil
+	case providerGitLab:
+		return gitlabProvider{}, nil
+	case providerBuildkite:
+		return buildkiteProvider{}, nil
+	}
+	return nil, fmt.Errorf("invalid ci-provider: %q, expected %s, %s or %s", name, providerGitHub, providerGitLab, providerBuildkite)
+}
+
+// spanNameValues are the placeholders available to the span-name input.
+func spanNameValues(params InputParams) map[string]string {
+	return params.Provider.SpanNameValues(params)
+}
+
+// githubProvider reads the job from the GITHUB_* and RUNNER_* environment of
+// GitHub Actions. Its inputs are always set by the action.
+type githubProvider struct{}
+
+func (githubProvider) Name() string {
+	return providerGitHub
+}
+
+func (githubProvider) ApplyDefaults(*InputParams) {}
+
+func (githubProvider) SpanNameValues(params InputParams) map[string]string {
+	job := params.JobName
+	if job == "" {
+		job = os.Getenv("GITHUB_JOB")
+	}
+	return map[string]string{
+		"workflow":    os.Getenv("GITHUB_WORKFLOW"),
+		"job":         job,
+		"repository":  os.Getenv("GITHUB_REPOSITORY"),
+		"ref":         os.Getenv("GITHUB_REF_NAME"),
+		"sha":         os.Getenv("GITHUB_SHA"),
+		"actor":       os.Getenv("GITHUB_ACTOR"),
+		"event":       os.Getenv("GITHUB_EVENT_NAME"),
+		"run_id":      os.Getenv("GITHUB_RUN_ID"),
+		"run_number":  os.Getenv("GITHUB_RUN_NUMBER"),
+		"run_attempt": os.Getenv("GITHUB_RUN_ATTEMPT"),
+		"runner_os":   os.Getenv("RUNNER_OS"),
+		"status":      params.JobStatus,
+	}
+}
+
+func (githubProvider) Attributes() []attribute.KeyValue {
+	return githubContextAttributes()
+}
+
+func (githubProvider) RunAttributes() []attribute.KeyValue {
+	run, ok := githubRun()
+	if !ok {
+		return nil
+	}
+	return []attribute.KeyValue{attribute.Int64("ci.github.workflow.run.attempt", run.RunAttempt)}
+}
+
+func (githubProvider) TraceID() (trace.TraceID, bool) {
+	run, ok := githubRun()
+	if !ok {
+		return trace.TraceID{}, false
+	}
+	return telemetry.RunTraceID(run.RunID, run.RunAttempt), true
+}
+
+func (githubProvider) JobSpanID(jobName string) (trace.SpanID, bool) {
+	run, ok := githubRun()
+	if !ok {
+		return trace.SpanID{}, false
+	}
+	return telemetry.JobSpanID(run.Repository, run.RunID, run.RunAttempt, jobName), true
+}
+
+// githubRun returns the context of the workflow run, if any, with the run
+// attempt defaulted to 1.
+func githubRun() (*githubactions.GitHubContext, bool) {
+	ghctx, err := githubactions.Context()
+	if err != nil || ghctx.RunID == 0 {
+		return nil, false
+	}
+	if ghctx.RunAttempt == 0 {
+		ghctx.RunAttempt = 1
+	}
+	return ghctx, true
+}
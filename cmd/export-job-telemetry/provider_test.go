@@ -0,0 +1,208 @@
+package main
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// attributeValues renders attributes as key to emitted value.
+func attributeValues(attributes []attribute.KeyValue) map[string]string {
+	values := make(map[string]string, len(attributes))
+	for _, kv := range attributes {
+		values[string(kv.Key)] = kv.Value.Emit()
+	}
+	return values
+}
+
+func TestCIProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: providerGitHub},
+		{name: "gitlab detected", env: map[string]string{"GITLAB_CI": "true"}, want: providerGitLab},
+		{name: "buildkite detected", env: map[string]string{"BUILDKITE": "true"}, want: providerBuildkite},
+		{name: "input wins", input: " Buildkite ", env: map[string]string{"GITLAB_CI": "true"}, want: providerBuildkite},
+		{name: "invalid", input: "jenkins", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("INPUT_CI-PROVIDER", tt.input)
+			t.Setenv("GITLAB_CI", "")
+			t.Setenv("BUILDKITE", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			provider, err := ciProvider()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ciProvider() = %s, want error", provider.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ciProvider() error: %v", err)
+			}
+			if got := provider.Name(); got != tt.want {
+				t.Errorf("ciProvider() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitLabProvider(t *testing.T) {
+	for k, v := range map[string]string{
+		"CI_JOB_STARTED_AT":         "2024-05-01T10:00:00Z",
+		"CI_JOB_STATUS":             "failed",
+		"CI_JOB_NAME":               "test",
+		"CI_JOB_ID":                 "7001",
+		"CI_JOB_STAGE":              "verify",
+		"CI_PIPELINE_ID":            "4001",
+		"CI_PIPELINE_IID":           "12",
+		"CI_PIPELINE_NAME":          "",
+		"CI_PIPELINE_SOURCE":        "merge_request_event",
+		"CI_PROJECT_PATH":           "group/project",
+		"CI_COMMIT_REF_NAME":        "main",
+		"CI_COMMIT_BRANCH":          "main",
+		"CI_COMMIT_TAG":             "",
+		"CI_COMMIT_SHA":             "abc123",
+		"CI_MERGE_REQUEST_IID":      "34",
+		"CI_RUNNER_EXECUTABLE_ARCH": "linux/amd64",
+		"CI_SERVER_URL":             "https://gitlab.example.com",
+	} {
+		t.Setenv(k, v)
+	}
+	provider := gitlabProvider{}
+
+	var params InputParams
+	provider.ApplyDefaults(&params)
+	if params.StartedAt != "2024-05-01T10:00:00Z" || params.JobStatus != "failure" || params.JobName != "test" {
+		t.Errorf("ApplyDefaults() = started-at %q, job-status %q, job-name %q, want 2024-05-01T10:00:00Z, failure, test", params.StartedAt, params.JobStatus, params.JobName)
+	}
+
+	params = InputParams{StartedAt: "2024-05-01T09:00:00Z", JobStatus: "success", JobName: "lint"}
+	provider.ApplyDefaults(&params)
+	if params.StartedAt != "2024-05-01T09:00:00Z" || params.JobStatus != "success" || params.JobName != "lint" {
+		t.Errorf("ApplyDefaults() overrode explicit inputs: %+v", params)
+	}
+
+	values := provider.SpanNameValues(InputParams{JobStatus: "failure"})
+	if values["job"] != "test" || values["runner_os"] != "linux" || values["run_number"] != "12" || values["status"] != "failure" {
+		t.Errorf("SpanNameValues() = %v", values)
+	}
+
+	attributes := attributeValues(provider.Attributes())
+	for key, want := range map[string]string{
+		"ci.github.workflow.run.id":     "4001",
+		"ci.github.workflow.run.number": "12",
+		"ci.github.workflow.job.name":   "test",
+		"ci.github.workflow.job.id":     "7001",
+		"ci.github.repository":          "group/project",
+		"ci.github.ref.type":            "branch",
+		"ci.github.pull_request.number": "34",
+		"ci.gitlab.job.stage":           "verify",
+	} {
+		if got := attributes[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if _, ok := attributes["ci.github.workflow.name"]; ok {
+		t.Error("empty CI_PIPELINE_NAME set ci.github.workflow.name")
+	}
+
+	traceID, ok := provider.TraceID()
+	if !ok || !traceID.IsValid() {
+		t.Fatalf("TraceID() = %s, %t, want a valid trace id", traceID, ok)
+	}
+	spanID, ok := provider.JobSpanID("test")
+	if !ok || !spanID.IsValid() {
+		t.Fatalf("JobSpanID() = %s, %t, want a valid span id", spanID, ok)
+	}
+
+	t.Setenv("CI_JOB_ID", "7002")
+	if retried, _ := provider.JobSpanID("test"); retried == spanID {
+		t.Error("JobSpanID() of a retried job is the same as of the first attempt")
+	}
+	t.Setenv("CI_PIPELINE_ID", "4002")
+	if other, _ := provider.TraceID(); other == traceID {
+		t.Error("TraceID() of another pipeline is the same")
+	}
+	t.Setenv("CI_PIPELINE_ID", "")
+	if _, ok := provider.TraceID(); ok {
+		t.Error("TraceID() without CI_PIPELINE_ID is ok")
+	}
+}
+
+func TestBuildkiteProvider(t *testing.T) {
+	for k, v := range map[string]string{
+		"BUILDKITE_COMMAND_EXIT_STATUS": "1",
+		"BUILDKITE_LABEL":               ":go: test",
+		"BUILDKITE_ORGANIZATION_SLUG":   "acme",
+		"BUILDKITE_PIPELINE_SLUG":       "api",
+		"BUILDKITE_PIPELINE_NAME":       "API",
+		"BUILDKITE_BUILD_ID":            "0190b3e4-0000-4000-8000-000000000000",
+		"BUILDKITE_BUILD_NUMBER":        "811",
+		"BUILDKITE_RETRY_COUNT":         "2",
+		"BUILDKITE_BRANCH":              "main",
+		"BUILDKITE_TAG":                 "",
+		"BUILDKITE_PULL_REQUEST":        "false",
+		"BUILDKITE_COMMIT":              "abc123",
+	} {
+		t.Setenv(k, v)
+	}
+	provider := buildkiteProvider{}
+
+	var params InputParams
+	provider.ApplyDefaults(&params)
+	if params.JobStatus != "failure" || params.JobName != ":go: test" {
+		t.Errorf("ApplyDefaults() = job-status %q, job-name %q, want failure, :go: test", params.JobStatus, params.JobName)
+	}
+	t.Setenv("BUILDKITE_COMMAND_EXIT_STATUS", "0")
+	params = InputParams{}
+	provider.ApplyDefaults(&params)
+	if params.JobStatus != "success" {
+		t.Errorf("ApplyDefaults() with exit status 0 = job-status %q, want success", params.JobStatus)
+	}
+
+	values := provider.SpanNameValues(params)
+	if values["repository"] != "acme/api" || values["ref"] != "main" || values["run_attempt"] != "3" {
+		t.Errorf("SpanNameValues() = %v", values)
+	}
+
+	attributes := attributeValues(provider.Attributes())
+	for key, want := range map[string]string{
+		"ci.github.workflow.name":        "API",
+		"ci.github.workflow.run.number":  "811",
+		"ci.github.workflow.run.attempt": "3",
+		"ci.github.repository":           "acme/api",
+		"ci.github.ref.type":             "branch",
+	} {
+		if got := attributes[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if _, ok := attributes["ci.github.pull_request.number"]; ok {
+		t.Error(`BUILDKITE_PULL_REQUEST=false set ci.github.pull_request.number`)
+	}
+
+	if traceID, ok := provider.TraceID(); !ok || !traceID.IsValid() {
+		t.Errorf("TraceID() = %s, %t, want a valid trace id", traceID, ok)
+	}
+	spanID, ok := provider.JobSpanID(":go: test")
+	if !ok || !spanID.IsValid() {
+		t.Fatalf("JobSpanID() = %s, %t, want a valid span id", spanID, ok)
+	}
+	t.Setenv("BUILDKITE_RETRY_COUNT", "3")
+	if retried, _ := provider.JobSpanID(":go: test"); retried == spanID {
+		t.Error("JobSpanID() of a retried job is the same as of the previous attempt")
+	}
+
+	t.Setenv("BUILDKITE_TAG", "v1.2.0")
+	if got := attributeValues(provider.Attributes())["ci.github.ref.name"]; got != "v1.2.0" {
+		t.Errorf("ci.github.ref.name of a tag build = %q, want v1.2.0", got)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// replayFiles forwards the spans of the OTLP JSON files written by the file
+// exporter, e.g. downloaded artifacts of air-gapped jobs, to every exporter.
+func replayFiles(params InputParams) error {
+	var paths []string
+	for _, pattern := range params.ReplayFiles {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid replay-files pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			githubactions.Warningf("No files match replay-files pattern %q", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		return errors.New("replay mode requires replay-files matching at least one file")
+	}
+
+	var resourceSpans []*tracepb.ResourceSpans
+	for _, path := range paths {
+		fileSpans, err := telemetry.ReadTracesFile(path)
+		if err != nil {
+			errorf("failed to read %s: %v", path, err)
+			continue
+		}
+		githubactions.Infof("Read %d span(s) from %s", telemetry.SpanCount(fileSpans), path)
+		resourceSpans = append(resourceSpans, fileSpans...)
+	}
+	if len(resourceSpans) == 0 {
+		return nil
+	}
+
+	count := telemetry.SpanCount(resourceSpans)
+	for _, exporter := range params.Exporters {
+		if err := telemetry.ReplayTraces(context.Background(), exporter, resourceSpans); err != nil {
+			errorf("failed to replay spans to %s: %v", exporter.Name(), err)
+			continue
+		}
+		githubactions.Infof("Replayed %d span(s) to %s", count, exporter.Name())
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// reusableWorkflowSpanName is the name of the span between the job that calls
+// a reusable workflow and the jobs of the called workflow.
+const reusableWorkflowSpanName = "Reusable workflow"
+
+// reusableJobSeparator separates the name of a job that calls a reusable
+// workflow from the names of the called jobs, e.g. "build / test".
+const reusableJobSeparator = " / "
+
+// workflowJobGroup is a job of a run, or a job that calls a reusable workflow
+// and groups the jobs of the called workflow.
+type workflowJobGroup struct {
+	name string
+	job  *workflowJob
+	jobs []*workflowJobGroup
+}
+
+// groupReusableWorkflowJobs nests the jobs of called reusable workflows under
+// their caller, keeping the order of jobs. GitHub lists the called jobs as
+// part of the caller's run, named after the caller job.
+func groupReusableWorkflowJobs(jobs []workflowJob) []*workflowJobGroup {
+	var root workflowJobGroup
+	for i := range jobs {
+		group := &root
+		names := splitJobName(jobs[i].Name)
+		for _, name := range names[:len(names)-1] {
+			group = group.child(name)
+		}
+		group.jobs = append(group.jobs, &workflowJobGroup{name: names[len(names)-1], job: &jobs[i]})
+	}
+	return root.jobs
+}
+
+// splitJobName splits the name of a called job into the names of its callers
+// and its own name. Separators within parentheses, i.e. in matrix values, are
+// part of the name.
+func splitJobName(name string) []string {
+	var names []string
+	depth, start := 0, 0
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '(':
+			depth++
+		case ')':
+			depth = max(depth-1, 0)
+		default:
+			if depth == 0 && strings.HasPrefix(name[i:], reusableJobSeparator) {
+				names = append(names, name[start:i])
+				start = i + len(reusableJobSeparator)
+				i = start - 1
+			}
+		}
+	}
+	return append(names, name[start:])
+}
+
+func (g *workflowJobGroup) child(name string) *workflowJobGroup {
+	for _, job := range g.jobs {
+		if job.job == nil && job.name == name {
+			return job
+		}
+	}
+	child := &workflowJobGroup{name: name}
+	g.jobs = append(g.jobs, child)
+	return child
+}
+
+// bounds spans the started jobs of the group.
+func (g *workflowJobGroup) bounds() (start, end time.Time, ok bool) {
+	if g.job != nil {
+		if g.job.StartedAt == nil {
+			return time.Time{}, time.Time{}, false
+		}
+		end = *g.job.StartedAt
+		if g.job.CompletedAt != nil {
+			end = *g.job.CompletedAt
+		}
+		return *g.job.StartedAt, end, true
+	}
+	for _, job := range g.jobs {
+		jobStart, jobEnd, jobOK := job.bounds()
+		if !jobOK {
+			continue
+		}
+		if !ok || jobStart.Before(start) {
+			start = jobStart
+		}
+		if !ok || jobEnd.After(end) {
+			end = jobEnd
+		}
+		ok = true
+	}
+	return start, end, ok
+}
+
+func (g *workflowJobGroup) conclusion() string {
+	if g.job != nil {
+		return g.job.Conclusion
+	}
+	conclusions := make([]string, 0, len(g.jobs))
+	for _, job := range g.jobs {
+		conclusions = append(conclusions, job.conclusion())
+	}
+	return telemetry.CombineConclusions(conclusions...)
+}
+
+// exportWorkflowJobGroups exports jobs as children of ctx. A job that calls a
+// reusable workflow gets a span spanning the called jobs, with a reusable
+// workflow span under it as the parent of the called jobs.
+func exportWorkflowJobGroups(ctx context.Context, tracer trace.Tracer, client *githubClient, owner, repo string, params InputParams, timing *workflowRunTiming, run workflowRun, path string, groups []*workflowJobGroup) []telemetry.StepLog {
+	var logs []telemetry.StepLog
+	for _, group := range groups {
+		if group.job != nil {
+			logs = append(logs, exportWorkflowJob(ctx, tracer, client, owner, repo, params, timing, group.name, *group.job)...)
+			continue
+		}
+
+		start, end, ok := group.bounds()
+		if !ok {
+			continue
+		}
+		callerName := path + group.name
+		conclusion := group.conclusion()
+
+		builder := reusableSpanBuilder(group.name, start, conclusion, params).
+			WithAttributes(
+				attribute.String("ci.github.workflow.job.name", callerName),
+				attribute.String("ci.github.workflow.job.conclusion", conclusion),
+				attribute.Bool("ci.github.workflow.job.calls_reusable_workflow", true),
+				attribute.Int64("ci.github.workflow.job.duration_ms", end.Sub(start).Milliseconds()),
+			)
+		if params.DeterministicSpanID {
+			builder.WithSpanID(telemetry.JobSpanID(owner+"/"+repo, run.ID, run.RunAttempt, callerName))
+		}
+		callerCtx, callerSpan := builder.Start(ctx, tracer)
+
+		_, description := params.StatusMapping.Status("Reusable workflow", conclusion, params.ErrorConclusions)
+		workflowCtx, workflowSpan := reusableSpanBuilder(reusableWorkflowSpanName, start, conclusion, params).
+			WithStatusMessage(description).
+			WithAttributes(attribute.String("ci.github.workflow.reusable_workflow.caller", callerName)).
+			Start(callerCtx, tracer)
+		logs = append(logs, exportWorkflowJobGroups(workflowCtx, tracer, client, owner, repo, params, timing, run, callerName+reusableJobSeparator, group.jobs)...)
+		workflowSpan.End(trace.WithTimestamp(end))
+		callerSpan.End(trace.WithTimestamp(end))
+	}
+	return logs
+}
+
+// reusableSpanBuilder returns a builder for the caller job and reusable
+// workflow spans, with the status and attributes every job span gets.
+func reusableSpanBuilder(name string, start time.Time, conclusion string, params InputParams) *telemetry.JobSpanBuilder {
+	return telemetry.NewJobSpanBuilder(name, start).
+		WithConclusion(conclusion).
+		WithErrorConclusions(params.ErrorConclusions).
+		WithStatusMapping(params.StatusMapping).
+		WithAttributeSchema(params.AttributeSchema).
+		WithAttributes(params.BaselineAttrs...).
+		WithAttributes(telemetry.BaggageAttributes(params.Baggage)...)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSplitJobName(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "build", want: []string{"build"}},
+		{name: "call / test", want: []string{"call", "test"}},
+		{name: "outer / inner / test", want: []string{"outer", "inner", "test"}},
+		{name: "call / test (linux / amd64)", want: []string{"call", "test (linux / amd64)"}},
+		{name: "call (a / b) / test", want: []string{"call (a / b)", "test"}},
+		{name: "build/test", want: []string{"build/test"}},
+	}
+	for _, tt := range tests {
+		if got := splitJobName(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitJobName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// describeGroups renders groups as name[children], with jobs by name only.
+func describeGroups(groups []*workflowJobGroup) string {
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if group.job != nil {
+			names = append(names, group.name)
+			continue
+		}
+		names = append(names, group.name+"["+describeGroups(group.jobs)+"]")
+	}
+	return strings.Join(names, ", ")
+}
+
+func TestGroupReusableWorkflowJobs(t *testing.T) {
+	jobs := []workflowJob{
+		{Name: "lint"},
+		{Name: "call / build"},
+		{Name: "call / test (linux / amd64)"},
+		{Name: "deploy"},
+		{Name: "call / nested / publish"},
+	}
+
+	groups := groupReusableWorkflowJobs(jobs)
+	if got, want := describeGroups(groups), "lint, call[build, test (linux / amd64), nested[publish]], deploy"; got != want {
+		t.Errorf("groupReusableWorkflowJobs() = %s, want %s", got, want)
+	}
+	if groups[1].jobs[1].job != &jobs[2] {
+		t.Error("grouped job does not refer to the listed job")
+	}
+}
+
+func TestWorkflowJobGroupBoundsAndConclusion(t *testing.T) {
+	at := func(minutes int) *time.Time {
+		ts := time.Date(2024, 5, 1, 10, minutes, 0, 0, time.UTC)
+		return &ts
+	}
+	groups := groupReusableWorkflowJobs([]workflowJob{
+		{Name: "call / build", Conclusion: "success", StartedAt: at(2), CompletedAt: at(5)},
+		{Name: "call / test", Conclusion: "failure", StartedAt: at(1), CompletedAt: at(4)},
+		{Name: "call / deploy", Conclusion: "skipped"},
+		{Name: "call / publish", StartedAt: at(6)},
+	})
+
+	start, end, ok := groups[0].bounds()
+	if !ok || !start.Equal(*at(1)) || !end.Equal(*at(6)) {
+		t.Errorf("bounds() = %s, %s, %t, want %s, %s, true", start, end, ok, at(1), at(6))
+	}
+	if got := groups[0].conclusion(); got != "failure" {
+		t.Errorf("conclusion() = %q, want failure", got)
+	}
+
+	if _, _, ok := groupReusableWorkflowJobs([]workflowJob{{Name: "call / queued"}})[0].bounds(); ok {
+		t.Error("bounds() of a group without started jobs is ok")
+	}
+}
+
+func TestExportWorkflowJobGroups(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	tracer := provider.Tracer(actionName)
+
+	at := func(minutes int) *time.Time {
+		ts := time.Date(2024, 5, 1, 10, minutes, 0, 0, time.UTC)
+		return &ts
+	}
+	jobs := []workflowJob{
+		{ID: 1, Name: "lint", Conclusion: "success", StartedAt: at(0), CompletedAt: at(1)},
+		{ID: 2, Name: "call / build", Conclusion: "success", StartedAt: at(1), CompletedAt: at(3)},
+		{ID: 3, Name: "call / test", Conclusion: "failure", StartedAt: at(2), CompletedAt: at(4)},
+	}
+
+	ctx, runSpan := tracer.Start(context.Background(), "CI")
+	exportWorkflowJobGroups(ctx, tracer, nil, "krzko", "export-job-telemetry", InputParams{ErrorConclusions: telemetry.DefaultErrorConclusions}, nil, workflowRun{ID: 42, RunAttempt: 1}, "", groupReusableWorkflowJobs(jobs))
+	runSpan.End()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	for name, parent := range map[string]string{
+		"lint":                   "CI",
+		"call":                   "CI",
+		reusableWorkflowSpanName: "call",
+		"build":                  reusableWorkflowSpanName,
+		"test":                   reusableWorkflowSpanName,
+	} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %q span exported", name)
+			continue
+		}
+		if span.Parent().SpanID() != spans[parent].SpanContext().SpanID() {
+			t.Errorf("%q is not a child of %q", name, parent)
+		}
+	}
+
+	caller := spans["call"]
+	if !caller.StartTime().Equal(*at(1)) || !caller.EndTime().Equal(*at(4)) {
+		t.Errorf("caller span = %s to %s, want %s to %s", caller.StartTime(), caller.EndTime(), at(1), at(4))
+	}
+	if caller.Status().Code != codes.Error || spans[reusableWorkflowSpanName].Status().Code != codes.Error {
+		t.Errorf("caller and reusable workflow status = %s, %s, want the failure of test", caller.Status().Code, spans[reusableWorkflowSpanName].Status().Code)
+	}
+	var callsReusable bool
+	for _, kv := range caller.Attributes() {
+		if kv.Key == "ci.github.workflow.job.calls_reusable_workflow" {
+			callsReusable = kv.Value.AsBool()
+		}
+	}
+	if !callsReusable {
+		t.Error("caller span does not set ci.github.workflow.job.calls_reusable_workflow")
+	}
+}
+
+func TestExportWorkflowJobGroupsAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	tracer := provider.Tracer(actionName)
+
+	members, err := telemetry.ParseBaggage("team=platform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := InputParams{
+		ErrorConclusions: telemetry.DefaultErrorConclusions,
+		AttributeSchema:  telemetry.AttributeSchemaCICD,
+		Baggage:          members,
+		BaselineAttrs:    []attribute.KeyValue{attribute.String("org", "acme")},
+	}
+	started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	jobs := []workflowJob{{ID: 1, Name: "call / build", Conclusion: "success", StartedAt: &started, CompletedAt: &started}}
+	exportWorkflowJobGroups(context.Background(), tracer, nil, "krzko", "export-job-telemetry", params, nil, workflowRun{ID: 42, RunAttempt: 1}, "", groupReusableWorkflowJobs(jobs))
+
+	spans := make(map[string]map[attribute.Key]string)
+	for _, span := range recorder.Ended() {
+		attributes := make(map[attribute.Key]string)
+		for _, kv := range span.Attributes() {
+			attributes[kv.Key] = kv.Value.Emit()
+		}
+		spans[span.Name()] = attributes
+	}
+	for _, name := range []string{"call", reusableWorkflowSpanName} {
+		attributes := spans[name]
+		if attributes["team"] != "platform" || attributes["org"] != "acme" {
+			t.Errorf("%q span attributes = %v, want the baggage and baseline attributes", name, attributes)
+		}
+	}
+	if caller := spans["call"]; caller["cicd.pipeline.task.name"] != "call" || caller["ci.github.workflow.job.name"] != "" {
+		t.Errorf("caller span attributes = %v, want the names of the cicd schema", caller)
+	}
+}
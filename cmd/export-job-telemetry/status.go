@@ -0,0 +1,31 @@
+package main
+
+import "github.com/krzko/export-job-telemetry/pkg/telemetry"
+
+const failedStepPlaceholder = "{failed_step}"
+
+// jobStatusMessage expands the status-message input for a job. values are the
+// span name placeholders, extended with {failed_step}.
+func jobStatusMessage(template string, values map[string]string, failedStep string) (string, error) {
+	values["failed_step"] = failedStep
+	return telemetry.ExpandStatusMessage(template, values)
+}
+
+// failedStep returns the name of the first failed step of the current job, or
+// "" when it could not be fetched.
+func (c *currentJob) failedStep() string {
+	if c == nil {
+		return ""
+	}
+	return c.job.failedStep()
+}
+
+// failedStep returns the name of the first step of the job that failed.
+func (j workflowJob) failedStep() string {
+	for _, step := range j.Steps {
+		if step.Conclusion == telemetry.ConclusionFailure {
+			return step.Name
+		}
+	}
+	return ""
+}
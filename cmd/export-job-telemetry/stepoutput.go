@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+// The runner removes the output file of a step once the next step starts, so
+// the outputs of prior steps are recorded per step id under RUNNER_TEMP, which
+// lives as long as the job. Any step can publish an output there by appending
+// it, in the GITHUB_OUTPUT format, to the file named after its id.
+const stepOutputsDir = "export-job-telemetry/step-outputs"
+
+// stepOutputsPath returns the file of the outputs recorded for stepID, or an
+// empty path when RUNNER_TEMP is not set.
+func stepOutputsPath(stepID string) (string, error) {
+	if stepID == "" || stepID != filepath.Base(stepID) || stepID == "." || stepID == ".." {
+		return "", fmt.Errorf("invalid step id %q", stepID)
+	}
+	runnerTemp := os.Getenv("RUNNER_TEMP")
+	if runnerTemp == "" {
+		return "", nil
+	}
+	return filepath.Join(runnerTemp, filepath.FromSlash(stepOutputsDir), stepID), nil
+}
+
+// recordStepOutput records an output of the current step, whose id the runner
+// passes as GITHUB_ACTION, for a later step to read with readStepOutput.
+func recordStepOutput(name, value string) {
+	stepID := os.Getenv("GITHUB_ACTION")
+	if stepID == "" {
+		return
+	}
+	path, err := stepOutputsPath(stepID)
+	if err != nil || path == "" {
+		return
+	}
+	if err := appendStepOutput(path, name, value); err != nil {
+		githubactions.Warningf("failed to record output %s of step %q: %v", name, stepID, err)
+	}
+}
+
+func appendStepOutput(path, name, value string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s=%s\n", name, value); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readStepOutput returns the output key recorded for the prior step stepID of
+// the current job, or an empty string when the step recorded none. When the
+// step recorded key more than once, the last value wins.
+func readStepOutput(stepID, key string) (string, error) {
+	path, err := stepOutputsPath(stepID)
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", errors.New("RUNNER_TEMP is not set")
+	}
+
+	outputs, err := parseOutputFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read outputs of step %q: %w", stepID, err)
+	}
+	return strings.TrimSpace(outputs[key]), nil
+}
+
+// parseOutputFile parses a file in the GITHUB_OUTPUT format, of name=value
+// lines and name<<delimiter multiline values.
+func parseOutputFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	outputs := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, delimiter, ok := strings.Cut(line, "<<"); ok && !strings.Contains(name, "=") {
+			var lines []string
+			for scanner.Scan() {
+				if scanner.Text() == delimiter {
+					break
+				}
+				lines = append(lines, scanner.Text())
+			}
+			outputs[name] = strings.Join(lines, "\n")
+			continue
+		}
+		if name, value, ok := strings.Cut(line, "="); ok {
+			outputs[name] = value
+		}
+	}
+
+	return outputs, scanner.Err()
+}
+
+// resolveTraceparent returns the traceparent output of the traceparent-from-step
+// step, falling back to the traceparent input when the step did not record one.
+func resolveTraceparent(params InputParams) string {
+	if params.TraceparentFromStep == "" {
+		return params.Traceparent
+	}
+
+	value, err := readStepOutput(params.TraceparentFromStep, "traceparent")
+	switch {
+	case err != nil:
+		githubactions.Warningf("failed to read the traceparent of step %q, falling back to the traceparent input: %v", params.TraceparentFromStep, err)
+	case value == "":
+		githubactions.Warningf("step %q recorded no traceparent output, falling back to the traceparent input", params.TraceparentFromStep)
+	default:
+		return value
+	}
+	return params.Traceparent
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testStepTraceparent  = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	testInputTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+)
+
+func TestReadStepOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    string
+		outputs string
+		want    string
+	}{
+		{name: "single line", step: "trace", outputs: "traceparent=" + testStepTraceparent + "\n", want: testStepTraceparent},
+		{name: "multiline value", step: "trace", outputs: "traceparent<<EOF\n " + testStepTraceparent + " \nEOF\n", want: testStepTraceparent},
+		{name: "equals sign in value", step: "trace", outputs: "traceparent=a=b\n", want: "a=b"},
+		{name: "other keys ignored", step: "trace", outputs: "trace-id=abc\nspan-id=def\n", want: ""},
+		{name: "last value wins", step: "trace", outputs: "traceparent=first\ntraceparent=second\n", want: "second"},
+		{name: "other step", step: "other", outputs: "traceparent=" + testStepTraceparent + "\n", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("RUNNER_TEMP", dir)
+			path := filepath.Join(dir, filepath.FromSlash(stepOutputsDir), "trace")
+			if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(tt.outputs), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readStepOutput(tt.step, "traceparent")
+			if err != nil {
+				t.Fatalf("readStepOutput() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readStepOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadStepOutputErrors(t *testing.T) {
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+	for _, step := range []string{"", ".", "..", "../trace", "a/b"} {
+		if _, err := readStepOutput(step, "traceparent"); err == nil {
+			t.Errorf("readStepOutput(%q) succeeded, want error", step)
+		}
+	}
+
+	t.Setenv("RUNNER_TEMP", "")
+	if _, err := readStepOutput("trace", "traceparent"); err == nil {
+		t.Error("readStepOutput() without RUNNER_TEMP succeeded, want error")
+	}
+}
+
+func TestRecordStepOutput(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("RUNNER_TEMP", dir)
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(dir, "github_output"))
+	t.Setenv("GITHUB_ACTION", "export")
+
+	setOutput("traceparent", testStepTraceparent)
+
+	got, err := readStepOutput("export", "traceparent")
+	if err != nil {
+		t.Fatalf("readStepOutput() error: %v", err)
+	}
+	if got != testStepTraceparent {
+		t.Errorf("readStepOutput() = %q, want %q", got, testStepTraceparent)
+	}
+}
+
+func TestResolveTraceparent(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("RUNNER_TEMP", dir)
+	path := filepath.Join(dir, filepath.FromSlash(stepOutputsDir), "trace")
+	if err := appendStepOutput(path, "traceparent", testStepTraceparent); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		params InputParams
+		want   string
+	}{
+		{name: "input", params: InputParams{Traceparent: testInputTraceparent}, want: testInputTraceparent},
+		{name: "step", params: InputParams{Traceparent: testInputTraceparent, TraceparentFromStep: "trace"}, want: testStepTraceparent},
+		{name: "step without input", params: InputParams{TraceparentFromStep: "trace"}, want: testStepTraceparent},
+		{name: "falls back to input", params: InputParams{Traceparent: testInputTraceparent, TraceparentFromStep: "missing"}, want: testInputTraceparent},
+		{name: "invalid step falls back to input", params: InputParams{Traceparent: testInputTraceparent, TraceparentFromStep: "../trace"}, want: testInputTraceparent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveTraceparent(tt.params); got != tt.want {
+				t.Errorf("resolveTraceparent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
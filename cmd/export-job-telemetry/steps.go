@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func fetchCurrentJob(ctx context.Context, client *githubClient, ghctx *githubactions.GitHubContext, params InputParams) (workflowJob, error) {
+	owner, repo := ghctx.Repo()
+	attempt := ghctx.RunAttempt
+	if attempt == 0 {
+		attempt = 1
+	}
+
+	jobs, err := client.listJobsForRunAttempt(ctx, owner, repo, ghctx.RunID, attempt)
+	if err != nil {
+		return workflowJob{}, fmt.Errorf("failed to list jobs for run %d: %w", ghctx.RunID, err)
+	}
+
+	job, ok := findCurrentJob(jobs, os.Getenv("RUNNER_NAME"), params.JobName)
+	if !ok {
+		return workflowJob{}, fmt.Errorf("failed to find the current job in run %d", ghctx.RunID)
+	}
+	return job, nil
+}
+
+// currentJob is the job the action runs in, with the client it was fetched
+// with.
+type currentJob struct {
+	client      *githubClient
+	owner, repo string
+	job         workflowJob
+}
+
+// exportsJobDetails reports whether the job span needs the current job from
+// the GitHub REST API.
+func exportsJobDetails(params InputParams) bool {
+	return params.ExportSteps || params.ExportAnnotations || params.ExportBillableTime || params.ExportLogs ||
+		strings.Contains(params.StatusMessage, failedStepPlaceholder)
+}
+
+// fetchCurrentJobDetails fetches the job the action runs in once, for both its
+// status message and its details. It returns nil, after warning, when the job
+// cannot be fetched.
+func fetchCurrentJobDetails(ctx context.Context, params InputParams) *currentJob {
+	if params.GitHubToken == "" {
+		githubactions.Warningf("github-token is required to fetch the current job")
+		return nil
+	}
+
+	ghctx, err := githubactions.Context()
+	if err != nil {
+		githubactions.Warningf("failed to read GitHub context: %v", err)
+		return nil
+	}
+	owner, repo := ghctx.Repo()
+	client := newGitHubClient(githubAPIURL(params, ghctx), params.GitHubToken)
+
+	job, err := fetchCurrentJob(ctx, client, ghctx, params)
+	if err != nil {
+		githubactions.Warningf("failed to fetch the current job: %v", err)
+		return nil
+	}
+	return &currentJob{client: client, owner: owner, repo: repo, job: job}
+}
+
+// exportCurrentJobDetails exports the steps, annotations, billable time and
+// failed step logs of the job the action runs in under span.
+func exportCurrentJobDetails(ctx context.Context, tracer trace.Tracer, params InputParams, res *resource.Resource, span trace.Span, current *currentJob, end time.Time) {
+	logs := exportJobDetails(ctx, tracer, current.client, current.owner, current.repo, params, nil, span, current.job, end)
+	exportLogs(ctx, params, res, logs)
+}
+
+// exportJobDetails exports the requested step spans, annotations and billable
+// time of job under span, and returns the logs of its failed steps when logs
+// are exported. timing is the billable time of the run, nil when it is not
+// exported.
+func exportJobDetails(ctx context.Context, tracer trace.Tracer, client *githubClient, owner, repo string, params InputParams, timing *workflowRunTiming, span trace.Span, job workflowJob, end time.Time) []telemetry.StepLog {
+	var stepSpans []trace.SpanContext
+	if params.ExportSteps {
+		stepSpans = telemetry.ExportStepSpans(ctx, tracer, job.telemetrySteps(), end, telemetry.StepSpanOptions{
+			Attributes:      params.BaselineAttrs,
+			AttributeSchema: params.AttributeSchema,
+		})
+	}
+
+	if params.ExportAnnotations {
+		if err := addJobAnnotations(ctx, client, owner, repo, span, job, end); err != nil {
+			githubactions.Warningf("failed to export annotations: %v", err)
+		}
+	}
+
+	if timing != nil {
+		addJobBilling(span, job, *timing, params.RunnerCostPerMinute)
+	}
+
+	if !params.ExportLogs {
+		return nil
+	}
+	logs, err := failedStepLogs(ctx, client, owner, repo, job, span.SpanContext(), stepSpans)
+	if err != nil {
+		githubactions.Warningf("failed to export logs of job %d: %v", job.ID, err)
+	}
+	return logs
+}
+
+func (j workflowJob) telemetrySteps() []telemetry.Step {
+	steps := make([]telemetry.Step, 0, len(j.Steps))
+	for _, step := range j.Steps {
+		steps = append(steps, telemetry.Step{
+			Name:        step.Name,
+			Number:      step.Number,
+			Status:      step.Status,
+			Conclusion:  step.Conclusion,
+			StartedAt:   step.StartedAt,
+			CompletedAt: step.CompletedAt,
+		})
+	}
+	return steps
+}
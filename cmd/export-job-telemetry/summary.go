@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// jobSummary is the job report written to the job summary when job-summary is
+// set.
+type jobSummary struct {
+	Name         string
+	Conclusion   string
+	Duration     *time.Duration
+	QueueLatency *time.Duration
+	TraceID      trace.TraceID
+	TraceURL     string
+	Attributes   []attribute.KeyValue
+}
+
+// Markdown renders the report. Attributes set more than once are listed with
+// their last value, as on the span, sorted by key.
+func (s jobSummary) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", markdownEscape(s.Name))
+	b.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Conclusion | `%s` |\n", markdownEscape(s.Conclusion))
+	if s.Duration != nil {
+		fmt.Fprintf(&b, "| Duration | %s |\n", formatDuration(*s.Duration))
+	}
+	if s.QueueLatency != nil {
+		fmt.Fprintf(&b, "| Queue latency | %s |\n", formatDuration(*s.QueueLatency))
+	}
+	fmt.Fprintf(&b, "| Trace | %s |\n", traceLink(s.TraceID, s.TraceURL))
+
+	if len(s.Attributes) > 0 {
+		b.WriteString("\n<details>\n<summary>Attributes</summary>\n\n")
+		b.WriteString("| Attribute | Value |\n|---|---|\n")
+		attributes := attribute.NewSet(s.Attributes...)
+		for _, kv := range attributes.ToSlice() {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", kv.Key, markdownEscape(kv.Value.Emit()))
+		}
+		b.WriteString("\n</details>\n")
+	}
+	return b.String()
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// markdownEscape keeps a value from breaking out of a Markdown table cell.
+func markdownEscape(value string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "<", "&lt;", ">", "&gt;").Replace(value)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/krzko/export-job-telemetry/pkg/telemetry"
+	"github.com/sethvargo/go-githubactions"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// exportWorkflowRun exports a completed workflow run as a root workflow span
+// with one child span per job, and optionally per step, using the timestamps
+// recorded by GitHub. It is meant to run from a workflow_run triggered workflow.
+func exportWorkflowRun(params InputParams, res *resource.Resource) error {
+	if params.GitHubToken == "" {
+		return fmt.Errorf("github-token is required in %s mode", modeWorkflowRun)
+	}
+
+	ghctx, err := githubactions.Context()
+	if err != nil {
+		return fmt.Errorf("failed to read GitHub context: %w", err)
+	}
+	owner, repo := ghctx.Repo()
+
+	runID, err := workflowRunID(params.RunID, ghctx.Event)
+	if err != nil {
+		return err
+	}
+
+	ctx := baggage.ContextWithBaggage(context.Background(), params.Baggage)
+	client := newGitHubClient(githubAPIURL(params, ghctx), params.GitHubToken)
+
+	run, err := client.getWorkflowRun(ctx, owner, repo, runID)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow run %d: %w", runID, err)
+	}
+
+	attempt := run.RunAttempt
+	if attempt == 0 {
+		attempt = 1
+	}
+	run.RunAttempt = attempt
+	jobs, err := client.listJobsForRunAttempt(ctx, owner, repo, runID, attempt)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs for run %d: %w", runID, err)
+	}
+
+	if traceparent := resolveTraceparent(params); traceparent != "" {
+		spanContext, err := telemetry.ParseTraceContext(traceparent, params.Tracestate)
+		if err != nil {
+			return err
+		}
+		if params.Debug {
+			logTraceparent(traceparent, spanContext)
+		}
+		ctx = trace.ContextWithRemoteSpanContext(ctx, spanContext)
+	}
+
+	// The status message of every job has the same placeholders, so a bad
+	// template fails the export before any span is started.
+	if params.StatusMessage != "" {
+		if _, err := jobStatusMessage(params.StatusMessage, spanNameValues(params), ""); err != nil {
+			return err
+		}
+	}
+
+	runStart, runEnd := workflowRunBounds(run, jobs)
+
+	tracer := otel.Tracer(actionName)
+	// Baseline attributes come first so that the attributes of the run
+	// override them.
+	runAttributes := params.AttributeSchema.Apply(append(slices.Clip(params.BaselineAttrs),
+		attribute.String("ci.github.workflow.name", run.Name),
+		attribute.Int64("ci.github.workflow.run.id", run.ID),
+		attribute.Int64("ci.github.workflow.run.number", run.RunNumber),
+		attribute.Int64("ci.github.workflow.run.attempt", attempt),
+		attribute.String("ci.github.workflow.run.event", run.Event),
+		attribute.String("ci.github.workflow.run.conclusion", run.Conclusion),
+		attribute.String("ci.github.workflow.run.head_branch", run.HeadBranch),
+		attribute.String("ci.github.workflow.run.head_sha", run.HeadSHA),
+		attribute.String("ci.github.workflow.run.url", run.HTMLURL),
+		attribute.Int64("ci.github.workflow.run.duration_ms", runEnd.Sub(runStart).Milliseconds()),
+	))
+	runAttributes = append(runAttributes, telemetry.BaggageAttributes(params.Baggage)...)
+
+	runCtx, runSpan := tracer.Start(ctx, run.Name,
+		trace.WithTimestamp(runStart),
+		trace.WithLinks(params.SpanLinks...),
+		trace.WithAttributes(runAttributes...),
+	)
+	runSpan.SetStatus(params.StatusMapping.Status("Workflow run", run.Conclusion, params.ErrorConclusions))
+
+	// The timing of the run holds the billable time of all of its jobs.
+	var timing *workflowRunTiming
+	if params.ExportBillableTime {
+		if runTiming, err := client.getWorkflowRunTiming(ctx, owner, repo, runID); err != nil {
+			githubactions.Warningf("failed to export billable time: failed to get the timing of run %d: %v", runID, err)
+		} else {
+			timing = &runTiming
+		}
+	}
+
+	var logs []telemetry.StepLog
+	if params.NestReusableWorkflows {
+		logs = exportWorkflowJobGroups(runCtx, tracer, client, owner, repo, params, timing, run, "", groupReusableWorkflowJobs(jobs))
+	} else {
+		for _, job := range jobs {
+			logs = append(logs, exportWorkflowJob(runCtx, tracer, client, owner, repo, params, timing, job.Name, job)...)
+		}
+	}
+
+	runSpan.End(trace.WithTimestamp(runEnd))
+	traceURL := setTraceOutputs(runSpan.SpanContext(), params.TraceURL)
+	addStepSummary("Exported trace " + traceLink(runSpan.SpanContext().TraceID(), traceURL))
+	exportLogs(ctx, params, res, logs)
+	githubactions.Infof("Exported workflow run %d with %d job(s)", run.ID, len(jobs))
+	return nil
+}
+
+func exportWorkflowJob(ctx context.Context, tracer trace.Tracer, client *githubClient, owner, repo string, params InputParams, timing *workflowRunTiming, spanName string, job workflowJob) []telemetry.StepLog {
+	if job.StartedAt == nil {
+		return nil
+	}
+
+	jobEnd := *job.StartedAt
+	if job.CompletedAt != nil {
+		jobEnd = *job.CompletedAt
+	}
+
+	builder := telemetry.NewJobSpanBuilder(spanName, *job.StartedAt).
+		WithConclusion(job.Conclusion).
+		WithErrorConclusions(params.ErrorConclusions).
+		WithStatusMapping(params.StatusMapping).
+		WithAttributeSchema(params.AttributeSchema).
+		WithAttributes(params.BaselineAttrs...).
+		WithAttributes(telemetry.BaggageAttributes(params.Baggage)...).
+		WithAttributes(
+			attribute.String("ci.github.workflow.job.name", job.Name),
+			attribute.Int64("ci.github.workflow.job.id", job.ID),
+			attribute.String("ci.github.workflow.job.conclusion", job.Conclusion),
+			attribute.String("ci.github.workflow.job.url", job.HTMLURL),
+			attribute.String("ci.github.workflow.job.runner.name", job.RunnerName),
+			attribute.StringSlice("ci.github.workflow.job.runner.labels", job.Labels),
+			attribute.Int64("ci.github.workflow.job.duration_ms", jobEnd.Sub(*job.StartedAt).Milliseconds()),
+		)
+	if job.CreatedAt != nil {
+		builder.WithAttributes(attribute.Int64("ci.github.workflow.job.start_latency_ms", job.StartedAt.Sub(*job.CreatedAt).Milliseconds()))
+	}
+
+	if params.StatusMessage != "" {
+		values := spanNameValues(params)
+		values["job"] = job.Name
+		values["status"] = job.Conclusion
+		// exportWorkflowRun has checked the template.
+		message, _ := jobStatusMessage(params.StatusMessage, values, job.failedStep())
+		builder.WithStatusMessage(message)
+	}
+
+	if params.DeterministicSpanID {
+		builder.WithSpanID(telemetry.JobSpanID(owner+"/"+repo, job.RunID, job.RunAttempt, job.Name))
+	}
+
+	jobCtx, span := builder.Start(ctx, tracer)
+	if params.ExportQueueSpan && job.CreatedAt != nil {
+		telemetry.ExportQueueSpan(jobCtx, tracer, *job.CreatedAt, *job.StartedAt, runnerAttributes(job.RunnerName, job.Labels)...)
+	}
+	logs := exportJobDetails(jobCtx, tracer, client, owner, repo, params, timing, span, job, jobEnd)
+	span.End(trace.WithTimestamp(jobEnd))
+	return logs
+}
+
+// workflowRunID resolves the run to export from the run-id input, falling back
+// to the run that triggered a workflow_run event.
+func workflowRunID(input string, event map[string]any) (int64, error) {
+	if input != "" {
+		runID, err := strconv.ParseInt(input, 10, 64)
+		if err != nil || runID <= 0 {
+			return 0, fmt.Errorf("invalid run-id: %q", input)
+		}
+		return runID, nil
+	}
+
+	if run, ok := event["workflow_run"].(map[string]any); ok {
+		if id, ok := run["id"].(float64); ok {
+			return int64(id), nil
+		}
+	}
+	return 0, fmt.Errorf("run-id is required in %s mode outside of a workflow_run event", modeWorkflowRun)
+}
+
+// workflowRunBounds spans the run from its start to the last job completion,
+// which is more accurate than updated_at for runs that were modified later.
+func workflowRunBounds(run workflowRun, jobs []workflowJob) (time.Time, time.Time) {
+	var start, end time.Time
+	switch {
+	case run.RunStartedAt != nil:
+		start = *run.RunStartedAt
+	case run.CreatedAt != nil:
+		start = *run.CreatedAt
+	}
+
+	for _, job := range jobs {
+		if job.CompletedAt != nil && job.CompletedAt.After(end) {
+			end = *job.CompletedAt
+		}
+	}
+	if end.IsZero() && run.UpdatedAt != nil {
+		end = *run.UpdatedAt
+	}
+	if end.Before(start) {
+		end = start
+	}
+	return start, end
+}
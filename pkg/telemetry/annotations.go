@@ -0,0 +1,54 @@
+package telemetry
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AnnotationLevelFailure is the level of annotations that record an error, such
+// as a failed step or an ::error:: workflow command.
+const AnnotationLevelFailure = "failure"
+
+// Annotation is a check run annotation of a job, e.g. a notice, warning or
+// error reported by one of its steps.
+type Annotation struct {
+	Level     string
+	Title     string
+	Message   string
+	Path      string
+	StartLine int64
+	EndLine   int64
+}
+
+// AddAnnotationEvents records annotations as span events at timestamp. Failure
+// annotations are also recorded as exception events, so the trace shows why the
+// job failed. The span status is left to the job's status mapping.
+func AddAnnotationEvents(span trace.Span, annotations []Annotation, timestamp time.Time) {
+	for _, annotation := range annotations {
+		attributes := []attribute.KeyValue{
+			attribute.String("ci.github.annotation.level", annotation.Level),
+			attribute.String("ci.github.annotation.message", annotation.Message),
+		}
+		if annotation.Title != "" {
+			attributes = append(attributes, attribute.String("ci.github.annotation.title", annotation.Title))
+		}
+		if annotation.Path != "" {
+			attributes = append(attributes,
+				attribute.String("ci.github.annotation.path", annotation.Path),
+				attribute.Int64("ci.github.annotation.start_line", annotation.StartLine),
+				attribute.Int64("ci.github.annotation.end_line", annotation.EndLine),
+			)
+		}
+		span.AddEvent("ci.github.annotation", trace.WithTimestamp(timestamp), trace.WithAttributes(attributes...))
+
+		if annotation.Level == AnnotationLevelFailure {
+			span.AddEvent(semconv.ExceptionEventName, trace.WithTimestamp(timestamp), trace.WithAttributes(
+				semconv.ExceptionType("ci.github.annotation"),
+				semconv.ExceptionMessage(annotation.Message),
+			))
+		}
+	}
+}
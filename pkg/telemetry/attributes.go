@@ -0,0 +1,262 @@
+package telemetry
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ParseKeyValuePairs parses comma-separated key=value pairs. Commas inside
+// square brackets do not separate pairs, so array values such as
+// regions=[eu,us] are kept whole. Values may be double-quoted, as in
+// targets="eu=1,us=2", and a backslash escapes a comma, equals sign, quote or
+// backslash, as in note=a\,b. Malformed pairs, such as those without an equals
+// sign, are ignored; see ParseKeyValuePairsWithErrors.
+func ParseKeyValuePairs(input string) map[string]string {
+	pairs, _ := ParseKeyValuePairsWithErrors(input)
+	return pairs
+}
+
+// ParseKeyValuePairsWithErrors is ParseKeyValuePairs, also returning an error
+// for each malformed pair it ignored. The errors identify pairs by position and
+// key only, as values may be secrets such as header tokens.
+func ParseKeyValuePairsWithErrors(input string) (map[string]string, []error) {
+	pairs := make(map[string]string)
+	var errs []error
+	for i, pair := range splitPairs(input) {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, err := parsePair(pair)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("pair %d: %w", i+1, err))
+			continue
+		}
+		pairs[key] = value
+	}
+	return pairs, errs
+}
+
+// splitPairs splits input at the commas that are not escaped, quoted or inside
+// square brackets.
+func splitPairs(input string) []string {
+	var pairs []string
+	depth, start := 0, 0
+	quoted, escaped := false, false
+	for i, r := range input {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[':
+			depth++
+		case r == ']':
+			if depth > 0 {
+				depth--
+			}
+		case r == ',':
+			if depth == 0 {
+				pairs = append(pairs, input[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(pairs, input[start:])
+}
+
+func parsePair(pair string) (string, string, error) {
+	i := unquotedIndex(pair, '=')
+	if i < 0 {
+		return "", "", fmt.Errorf("missing =")
+	}
+	key, err := unquote(strings.TrimSpace(pair[:i]))
+	if err != nil {
+		return "", "", err
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("empty key")
+	}
+	value, err := unquote(pair[i+1:])
+	if err != nil {
+		return "", "", fmt.Errorf("key %q: %w", key, err)
+	}
+	return key, value, nil
+}
+
+// unquotedIndex returns the index of the first c in s that is neither escaped
+// nor quoted, or -1.
+func unquotedIndex(s string, c rune) int {
+	quoted, escaped := false, false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && r == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// unquote strips the quotes of a quoted value and resolves the escapes of
+// commas, equals signs, quotes and backslashes. Other backslashes are kept, so
+// values such as Windows paths are unchanged.
+func unquote(s string) (string, error) {
+	quoted := false
+	if trimmed := strings.TrimSpace(s); strings.HasPrefix(trimmed, `"`) {
+		s, quoted = trimmed[1:], true
+	}
+
+	var b strings.Builder
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			if !strings.ContainsRune(`,="\`, r) {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quoted && r == '"':
+			if i != len(s)-1 {
+				return "", fmt.Errorf("unexpected text after closing quote")
+			}
+			return b.String(), nil
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if quoted {
+		return "", fmt.Errorf("unterminated quote")
+	}
+	if escaped {
+		b.WriteRune('\\')
+	}
+	return b.String(), nil
+}
+
+// MergeKeyValuePairs merges maps in increasing order of precedence, so a key in
+// a later map overrides the same key in an earlier one.
+func MergeKeyValuePairs(layers ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, layer := range layers {
+		for k, v := range layer {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// Attribute types accepted as a key suffix, e.g. build.number:string=0042.
+const (
+	AttributeTypeString      = "string"
+	AttributeTypeInt         = "int"
+	AttributeTypeFloat       = "float"
+	AttributeTypeBool        = "bool"
+	AttributeTypeStringArray = "string[]"
+)
+
+// TypedAttributes converts key=value pairs to attributes, sorted by key. See
+// TypedAttribute for how the type of each value is chosen.
+func TypedAttributes(pairs map[string]string) ([]attribute.KeyValue, error) {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]attribute.KeyValue, 0, len(pairs))
+	for _, k := range keys {
+		kv, err := TypedAttribute(k, pairs[k])
+		if err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, kv)
+	}
+	return attributes, nil
+}
+
+// TypedAttribute converts a key=value pair to an attribute. A key may end in a
+// type suffix, such as retries:int, which the value must then parse as.
+// Without a suffix the type is inferred: true and false are booleans, values
+// in square brackets are string arrays, and numbers are ints or floats when
+// they are written in canonical form, so that values like 007 or 1.20 stay
+// strings. Everything else is a string.
+func TypedAttribute(key, value string) (attribute.KeyValue, error) {
+	if i := strings.LastIndex(key, ":"); i >= 0 {
+		name, attributeType := key[:i], key[i+1:]
+		switch attributeType {
+		case AttributeTypeString:
+			return attribute.String(name, value), nil
+		case AttributeTypeInt:
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return attribute.KeyValue{}, fmt.Errorf("invalid int value for attribute %q: %q", name, value)
+			}
+			return attribute.Int64(name, n), nil
+		case AttributeTypeFloat:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return attribute.KeyValue{}, fmt.Errorf("invalid float value for attribute %q: %q", name, value)
+			}
+			return attribute.Float64(name, f), nil
+		case AttributeTypeBool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return attribute.KeyValue{}, fmt.Errorf("invalid bool value for attribute %q: %q", name, value)
+			}
+			return attribute.Bool(name, b), nil
+		case AttributeTypeStringArray:
+			values, ok := parseArray(value)
+			if !ok {
+				return attribute.KeyValue{}, fmt.Errorf("invalid string[] value for attribute %q: %q, expected [a,b]", name, value)
+			}
+			return attribute.StringSlice(name, values), nil
+		}
+	}
+
+	switch value {
+	case "true":
+		return attribute.Bool(key, true), nil
+	case "false":
+		return attribute.Bool(key, false), nil
+	}
+	if values, ok := parseArray(value); ok {
+		return attribute.StringSlice(key, values), nil
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil && strconv.FormatInt(n, 10) == value {
+		return attribute.Int64(key, n), nil
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == value {
+		return attribute.Float64(key, f), nil
+	}
+	return attribute.String(key, value), nil
+}
+
+func parseArray(value string) ([]string, bool) {
+	if len(value) < 2 || value[0] != '[' || value[len(value)-1] != ']' {
+		return nil, false
+	}
+	inner := strings.TrimSpace(value[1 : len(value)-1])
+	if inner == "" {
+		return []string{}, true
+	}
+	values := strings.Split(inner, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return values, true
+}
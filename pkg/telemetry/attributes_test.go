@@ -0,0 +1,122 @@
+package telemetry
+
+import (
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestParseKeyValuePairs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     map[string]string
+		wantErrs int
+	}{
+		{name: "empty", input: "", want: map[string]string{}},
+		{name: "pairs", input: "a=1,b=2", want: map[string]string{"a": "1", "b": "2"}},
+		{name: "trimmed key", input: " a=1, b=2", want: map[string]string{"a": "1", "b": "2"}},
+		{name: "empty value", input: "a=", want: map[string]string{"a": ""}},
+		{name: "equals sign in value", input: "token=a=b", want: map[string]string{"token": "a=b"}},
+		{name: "array", input: "regions=[eu,us],tier=gold", want: map[string]string{"regions": "[eu,us]", "tier": "gold"}},
+		{name: "quoted value", input: `targets="eu=1,us=2",tier=gold`, want: map[string]string{"targets": "eu=1,us=2", "tier": "gold"}},
+		{name: "escaped comma", input: `note=a\,b`, want: map[string]string{"note": "a,b"}},
+		{name: "escaped quote", input: `note=say \"hi\"`, want: map[string]string{"note": `say "hi"`}},
+		{name: "other backslashes kept", input: `path=C:\tmp\cache`, want: map[string]string{"path": `C:\tmp\cache`}},
+		{name: "later key wins", input: "a=1,a=2", want: map[string]string{"a": "2"}},
+		{name: "malformed pairs skipped", input: "a=1,bad,=x,c=3", want: map[string]string{"a": "1", "c": "3"}, wantErrs: 2},
+		{name: "unterminated quote", input: `a="open,b=2`, want: map[string]string{}, wantErrs: 1},
+		{name: "text after closing quote", input: `a="x"y`, want: map[string]string{}, wantErrs: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := ParseKeyValuePairsWithErrors(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeyValuePairsWithErrors(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+			if len(errs) != tt.wantErrs {
+				t.Errorf("ParseKeyValuePairsWithErrors(%q) returned %d errors, want %d: %v", tt.input, len(errs), tt.wantErrs, errs)
+			}
+			if got := ParseKeyValuePairs(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeyValuePairs(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeKeyValuePairs(t *testing.T) {
+	got := MergeKeyValuePairs(
+		map[string]string{"team": "platform", "tier": "gold"},
+		nil,
+		map[string]string{"team": "payments"},
+	)
+	want := map[string]string{"team": "payments", "tier": "gold"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeKeyValuePairs() = %v, want %v", got, want)
+	}
+}
+
+func TestTypedAttribute(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		want    attribute.KeyValue
+		wantErr bool
+	}{
+		{key: "flaky", value: "true", want: attribute.Bool("flaky", true)},
+		{key: "flaky", value: "false", want: attribute.Bool("flaky", false)},
+		{key: "retries", value: "3", want: attribute.Int64("retries", 3)},
+		{key: "offset", value: "-3", want: attribute.Int64("offset", -3)},
+		{key: "ratio", value: "1.5", want: attribute.Float64("ratio", 1.5)},
+		{key: "regions", value: "[eu, us]", want: attribute.StringSlice("regions", []string{"eu", "us"})},
+		{key: "regions", value: "[]", want: attribute.StringSlice("regions", []string{})},
+		{key: "build", value: "007", want: attribute.String("build", "007")},
+		{key: "version", value: "1.20", want: attribute.String("version", "1.20")},
+		{key: "flaky", value: "True", want: attribute.String("flaky", "True")},
+		{key: "team", value: "platform", want: attribute.String("team", "platform")},
+		{key: "build.number:string", value: "0042", want: attribute.String("build.number", "0042")},
+		{key: "retries:int", value: "3", want: attribute.Int64("retries", 3)},
+		{key: "ratio:float", value: "2", want: attribute.Float64("ratio", 2)},
+		{key: "flaky:bool", value: "1", want: attribute.Bool("flaky", true)},
+		{key: "tags:string[]", value: "[a,b]", want: attribute.StringSlice("tags", []string{"a", "b"})},
+		{key: "host:port", value: "8080", want: attribute.Int64("host:port", 8080)},
+		{key: "retries:int", value: "three", wantErr: true},
+		{key: "ratio:float", value: "half", wantErr: true},
+		{key: "flaky:bool", value: "maybe", wantErr: true},
+		{key: "tags:string[]", value: "a,b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			got, err := TypedAttribute(tt.key, tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("TypedAttribute(%q, %q) = %v, want error", tt.key, tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TypedAttribute(%q, %q) error: %v", tt.key, tt.value, err)
+			}
+			if got.Key != tt.want.Key || got.Value.Type() != tt.want.Value.Type() || !reflect.DeepEqual(got.Value.AsInterface(), tt.want.Value.AsInterface()) {
+				t.Errorf("TypedAttribute(%q, %q) = %s %s(%s), want %s %s(%s)", tt.key, tt.value,
+					got.Key, got.Value.Type(), got.Value.Emit(), tt.want.Key, tt.want.Value.Type(), tt.want.Value.Emit())
+			}
+		})
+	}
+}
+
+func TestTypedAttributes(t *testing.T) {
+	got, err := TypedAttributes(map[string]string{"b": "2", "a": "x", "c:bool": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []attribute.KeyValue{attribute.String("a", "x"), attribute.Int64("b", 2), attribute.Bool("c", true)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TypedAttributes() = %v, want %v", got, want)
+	}
+
+	if _, err := TypedAttributes(map[string]string{"n:int": "x"}); err == nil {
+		t.Error("TypedAttributes() with an invalid typed value succeeded, want error")
+	}
+}
@@ -0,0 +1,31 @@
+package telemetry
+
+import (
+	"fmt"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// ParseBaggage parses a W3C baggage header value, e.g.
+// team=platform,environment=production.
+func ParseBaggage(value string) (baggage.Baggage, error) {
+	b, err := baggage.Parse(value)
+	if err != nil {
+		return baggage.Baggage{}, fmt.Errorf("invalid baggage: %w", err)
+	}
+	return b, nil
+}
+
+// BaggageAttributes returns the baggage members as string attributes, sorted
+// by key. Member properties are dropped.
+func BaggageAttributes(b baggage.Baggage) []attribute.KeyValue {
+	members := b.Members()
+	attributes := make([]attribute.KeyValue, 0, len(members))
+	for _, member := range members {
+		attributes = append(attributes, attribute.String(member.Key(), member.Value()))
+	}
+	sort.Slice(attributes, func(i, j int) bool { return attributes[i].Key < attributes[j].Key })
+	return attributes
+}
@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Conclusions reported by GitHub for jobs, steps and workflow runs.
+const (
+	ConclusionSuccess        = "success"
+	ConclusionFailure        = "failure"
+	ConclusionCancelled      = "cancelled"
+	ConclusionSkipped        = "skipped"
+	ConclusionTimedOut       = "timed_out"
+	ConclusionNeutral        = "neutral"
+	ConclusionActionRequired = "action_required"
+	ConclusionStale          = "stale"
+)
+
+// DefaultErrorConclusions are the conclusions that map to an error status
+// unless configured otherwise.
+var DefaultErrorConclusions = []string{ConclusionFailure, ConclusionTimedOut}
+
+var conclusionDescriptions = map[string]string{
+	ConclusionSuccess:        "completed successfully",
+	ConclusionFailure:        "failed",
+	ConclusionCancelled:      "was cancelled",
+	ConclusionSkipped:        "was skipped",
+	ConclusionTimedOut:       "timed out",
+	ConclusionNeutral:        "completed with a neutral conclusion",
+	ConclusionActionRequired: "requires action",
+	ConclusionStale:          "went stale",
+}
+
+// ConclusionStatus maps the conclusion of subject, e.g. "Job", to a span
+// status. Conclusions in errorConclusions map to Error, other known
+// conclusions to Ok, and unknown or empty conclusions to Unset.
+func ConclusionStatus(subject, conclusion string, errorConclusions []string) (codes.Code, string) {
+	conclusion = strings.ToLower(strings.TrimSpace(conclusion))
+	description, known := conclusionDescriptions[conclusion]
+	if !known {
+		description = "conclusion " + conclusion
+	}
+	description = subject + " " + description
+
+	for _, errorConclusion := range errorConclusions {
+		if conclusion != "" && conclusion == errorConclusion {
+			return codes.Error, description
+		}
+	}
+	if !known {
+		return codes.Unset, subject + " status unknown"
+	}
+	return codes.Ok, description
+}
+
+// ParseConclusions parses a comma-separated list of conclusions.
+func ParseConclusions(input string) []string {
+	var conclusions []string
+	for _, conclusion := range strings.Split(input, ",") {
+		if conclusion = strings.ToLower(strings.TrimSpace(conclusion)); conclusion != "" {
+			conclusions = append(conclusions, conclusion)
+		}
+	}
+	return conclusions
+}
+
+// conclusionPrecedence orders conclusions from the one that dominates a group
+// of jobs to the one that is dominated.
+var conclusionPrecedence = []string{
+	ConclusionFailure,
+	ConclusionTimedOut,
+	ConclusionCancelled,
+	ConclusionActionRequired,
+	ConclusionStale,
+	ConclusionSuccess,
+	ConclusionNeutral,
+	ConclusionSkipped,
+}
+
+// CombineConclusions returns the conclusion of a group of jobs, such as the
+// jobs of a reusable workflow: the first failing conclusion, otherwise success
+// unless every job was neutral or skipped. It returns an empty conclusion when
+// none of conclusions is known.
+func CombineConclusions(conclusions ...string) string {
+	present := make(map[string]bool, len(conclusions))
+	for _, conclusion := range conclusions {
+		present[strings.ToLower(strings.TrimSpace(conclusion))] = true
+	}
+	for _, conclusion := range conclusionPrecedence {
+		if present[conclusion] {
+			return conclusion
+		}
+	}
+	return ""
+}
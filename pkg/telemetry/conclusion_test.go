@@ -0,0 +1,73 @@
+package telemetry
+
+import (
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestConclusionStatus(t *testing.T) {
+	tests := []struct {
+		conclusion       string
+		errorConclusions []string
+		wantCode         codes.Code
+		wantDescription  string
+	}{
+		{conclusion: "success", errorConclusions: DefaultErrorConclusions, wantCode: codes.Ok, wantDescription: "Job completed successfully"},
+		{conclusion: "failure", errorConclusions: DefaultErrorConclusions, wantCode: codes.Error, wantDescription: "Job failed"},
+		{conclusion: "timed_out", errorConclusions: DefaultErrorConclusions, wantCode: codes.Error, wantDescription: "Job timed out"},
+		{conclusion: "cancelled", errorConclusions: DefaultErrorConclusions, wantCode: codes.Ok, wantDescription: "Job was cancelled"},
+		{conclusion: "skipped", errorConclusions: DefaultErrorConclusions, wantCode: codes.Ok, wantDescription: "Job was skipped"},
+		{conclusion: " Failure ", errorConclusions: DefaultErrorConclusions, wantCode: codes.Error, wantDescription: "Job failed"},
+		{conclusion: "cancelled", errorConclusions: []string{"failure", "cancelled"}, wantCode: codes.Error, wantDescription: "Job was cancelled"},
+		{conclusion: "failure", errorConclusions: []string{}, wantCode: codes.Ok, wantDescription: "Job failed"},
+		{conclusion: "", errorConclusions: DefaultErrorConclusions, wantCode: codes.Unset, wantDescription: "Job status unknown"},
+		{conclusion: "exploded", errorConclusions: DefaultErrorConclusions, wantCode: codes.Unset, wantDescription: "Job status unknown"},
+		{conclusion: "exploded", errorConclusions: []string{"exploded"}, wantCode: codes.Error, wantDescription: "Job conclusion exploded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.conclusion, func(t *testing.T) {
+			code, description := ConclusionStatus("Job", tt.conclusion, tt.errorConclusions)
+			if code != tt.wantCode || description != tt.wantDescription {
+				t.Errorf("ConclusionStatus(%q, %v) = %s %q, want %s %q", tt.conclusion, tt.errorConclusions, code, description, tt.wantCode, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestParseConclusions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "", want: nil},
+		{input: "failure", want: []string{"failure"}},
+		{input: " Failure, timed_out,,cancelled ", want: []string{"failure", "timed_out", "cancelled"}},
+	}
+	for _, tt := range tests {
+		if got := ParseConclusions(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseConclusions(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCombineConclusions(t *testing.T) {
+	tests := []struct {
+		conclusions []string
+		want        string
+	}{
+		{conclusions: nil, want: ""},
+		{conclusions: []string{"success", "skipped"}, want: "success"},
+		{conclusions: []string{"success", "failure", "cancelled"}, want: "failure"},
+		{conclusions: []string{"cancelled", "timed_out"}, want: "timed_out"},
+		{conclusions: []string{"skipped", "neutral"}, want: "neutral"},
+		{conclusions: []string{"skipped"}, want: "skipped"},
+		{conclusions: []string{"unknown"}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := CombineConclusions(tt.conclusions...); got != tt.want {
+			t.Errorf("CombineConclusions(%v) = %q, want %q", tt.conclusions, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConnectivityError describes why an endpoint could not be reached, with a hint
+// on how to fix it.
+type ConnectivityError struct {
+	// Stage is the step that failed: dns, tls, auth or export.
+	Stage string
+	Hint  string
+	Err   error
+}
+
+func (e *ConnectivityError) Error() string {
+	return fmt.Sprintf("%s check failed: %v (%s)", e.Stage, e.Err, e.Hint)
+}
+
+func (e *ConnectivityError) Unwrap() error {
+	return e.Err
+}
+
+// CheckConnectivity verifies that spans can be exported to an otlp exporter by
+// resolving its host and sending it an empty export request, with the
+// configured TLS settings and headers, so problems surface before spans are
+// dropped at shutdown. Exports are not retried. The console and file
+// exporters always pass.
+func CheckConnectivity(ctx context.Context, cfg ExporterConfig) error {
+	if cfg.Type != ExporterTypeOTLP {
+		return nil
+	}
+
+	// A custom dialer resolves the endpoint itself, if at all.
+	if !cfg.customDialer() {
+		if err := checkDNS(ctx, cfg); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Protocol == ProtocolHTTPProtobuf {
+		if err := checkHTTPExport(ctx, cfg); err != nil {
+			return classifyExportError(err)
+		}
+		return nil
+	}
+
+	cfg.Retry = &RetryConfig{}
+	client, err := NewTraceClient(cfg)
+	if err != nil {
+		return err
+	}
+	if err := client.Start(ctx); err != nil {
+		return classifyExportError(err)
+	}
+	err = client.UploadTraces(ctx, nil)
+	_ = client.Stop(ctx)
+	if err != nil {
+		return classifyExportError(err)
+	}
+	return nil
+}
+
+// httpStatusError is a response other than 2xx to an OTLP/HTTP connectivity
+// check.
+type httpStatusError struct {
+	URL        string
+	StatusCode int
+	// Body is the start of the response body, which may explain the error.
+	Body string
+}
+
+func (e *httpStatusError) Error() string {
+	message := fmt.Sprintf("failed to send to %s: %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Body != "" {
+		message += ": " + e.Body
+	}
+	return message
+}
+
+// maxErrorBodySize limits how much of an error response is read into
+// httpStatusError.
+const maxErrorBodySize = 4 << 10
+
+// checkHTTPExport sends an empty OTLP/HTTP export request with the transport
+// settings of the trace exporter. The OTLP/HTTP client only reports the status
+// of a failed response in its message, so the check sends the request itself
+// to classify the response by its status code.
+func checkHTTPExport(ctx context.Context, cfg ExporterConfig) error {
+	proxy, stopProxy, err := cfg.httpProxy()
+	if err != nil {
+		return err
+	}
+	defer stopProxy()
+
+	endpointURL, err := cfg.tracesURL()
+	if err != nil {
+		return err
+	}
+	switch {
+	case cfg.Insecure:
+		endpointURL.Scheme = "http"
+	case endpointURL.Scheme == "":
+		endpointURL.Scheme = "https"
+	}
+	transport := &http.Transport{Proxy: proxy}
+	if endpointURL.Scheme == "https" {
+		if transport.TLSClientConfig, err = cfg.TLSConfig(); err != nil {
+			return err
+		}
+	}
+	client := &http.Client{Transport: transport}
+	defer client.CloseIdleConnections()
+
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL.String(), http.NoBody)
+	if err != nil {
+		return err
+	}
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return &httpStatusError{URL: endpointURL.String(), StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	return nil
+}
+
+func checkDNS(ctx context.Context, cfg ExporterConfig) error {
+	host, err := cfg.endpointHost()
+	if err != nil {
+		return err
+	}
+	proxy, err := cfg.proxy()
+	if err != nil {
+		return err
+	}
+	// Behind a proxy the endpoint is resolved by the proxy.
+	if proxyURL, err := proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: host}}); err == nil && proxyURL == nil {
+		if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+			return &ConnectivityError{Stage: "dns", Hint: fmt.Sprintf("check that the endpoint host %q is spelled correctly and resolvable from the runner", host), Err: err}
+		}
+	}
+	return nil
+}
+
+func (c ExporterConfig) endpointHost() (string, error) {
+	if c.Protocol == ProtocolHTTPProtobuf {
+		u, err := c.tracesURL()
+		if err != nil {
+			return "", err
+		}
+		return u.Hostname(), nil
+	}
+	hostport := c.Endpoint
+	if i := strings.Index(hostport, "://"); i >= 0 {
+		hostport = hostport[i+3:]
+	}
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return "", fmt.Errorf("invalid OTLP endpoint %q: %w", c.Endpoint, err)
+	}
+	return host, nil
+}
+
+// classifyExportError maps the error of an export to the likely cause, from
+// the type of the error or its status code. gRPC flattens connection errors
+// into the message of an Unavailable status, so only those are recognized by
+// their text.
+func classifyExportError(err error) error {
+	var (
+		dnsErr    *net.DNSError
+		statusErr *httpStatusError
+	)
+	switch {
+	case errors.As(err, &dnsErr):
+		return &ConnectivityError{Stage: "dns", Hint: hintDNS, Err: err}
+	case isTLSError(err):
+		return &ConnectivityError{Stage: "tls", Hint: hintTLS, Err: err}
+	case errors.As(err, &statusErr):
+		switch statusErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return &ConnectivityError{Stage: "auth", Hint: hintAuth, Err: err}
+		case http.StatusNotFound:
+			return &ConnectivityError{Stage: "export", Hint: "the endpoint has no OTLP traces path, check otel-exporter-otlp-endpoint and otel-exporter-otlp-protocol", Err: err}
+		}
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return &ConnectivityError{Stage: "export", Hint: hintRefused, Err: err}
+	case isTimeout(err):
+		return &ConnectivityError{Stage: "export", Hint: hintTimeout, Err: err}
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unauthenticated, codes.PermissionDenied:
+			return &ConnectivityError{Stage: "auth", Hint: hintAuth, Err: err}
+		case codes.Unimplemented:
+			return &ConnectivityError{Stage: "export", Hint: "the endpoint does not serve OTLP traces over gRPC, check the port and otel-exporter-otlp-protocol", Err: err}
+		case codes.DeadlineExceeded:
+			return &ConnectivityError{Stage: "export", Hint: hintTimeout, Err: err}
+		case codes.Unavailable:
+			message := s.Message()
+			switch {
+			case strings.Contains(message, "no such host") || strings.Contains(message, "produced zero addresses"):
+				return &ConnectivityError{Stage: "dns", Hint: hintDNS, Err: err}
+			case strings.Contains(message, "x509:") || strings.Contains(message, "tls:") || strings.Contains(message, "handshake"):
+				return &ConnectivityError{Stage: "tls", Hint: hintTLS, Err: err}
+			case strings.Contains(message, "connection refused"):
+				return &ConnectivityError{Stage: "export", Hint: hintRefused, Err: err}
+			}
+		}
+	}
+	return &ConnectivityError{Stage: "export", Hint: "check otel-exporter-otlp-endpoint and otel-exporter-otlp-protocol", Err: err}
+}
+
+const (
+	hintDNS     = "check that the endpoint host is resolvable from the runner"
+	hintTLS     = "the TLS handshake failed, check otel-exporter-otlp-ca-cert and otel-exporter-otlp-insecure, and that the endpoint serves TLS"
+	hintAuth    = "the endpoint rejected the credentials, check otel-exporter-otlp-headers"
+	hintRefused = "nothing is listening on the endpoint, check its port"
+	hintTimeout = "the endpoint did not respond in time, check that it is reachable from the runner, e.g. through a firewall or proxy"
+)
+
+// isTLSError reports whether err is a failed TLS handshake or certificate
+// verification.
+func isTLSError(err error) bool {
+	var (
+		verificationErr *tls.CertificateVerificationError
+		recordErr       tls.RecordHeaderError
+		alertErr        tls.AlertError
+		authorityErr    x509.UnknownAuthorityError
+		hostnameErr     x509.HostnameError
+		invalidErr      x509.CertificateInvalidError
+	)
+	return errors.As(err, &verificationErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
+
+// isTimeout reports whether err is a deadline or network timeout.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
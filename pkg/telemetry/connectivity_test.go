@@ -0,0 +1,124 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyExportError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+
+	tests := []struct {
+		name      string
+		err       error
+		wantStage string
+		wantHint  string
+	}{
+		{name: "DNS", err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "collector.invalid", IsNotFound: true}}, wantStage: "dns", wantHint: hintDNS},
+		{name: "refused", err: fmt.Errorf("Post %q: %w", "http://localhost:4318/v1/traces", refused), wantStage: "export", wantHint: hintRefused},
+		{name: "unknown authority", err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}, wantStage: "tls", wantHint: hintTLS},
+		{name: "wrong host", err: x509.HostnameError{Certificate: &x509.Certificate{}, Host: "collector"}, wantStage: "tls", wantHint: hintTLS},
+		{name: "plaintext endpoint", err: tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, wantStage: "tls", wantHint: hintTLS},
+		{name: "TLS alert", err: fmt.Errorf("remote error: %w", tls.AlertError(40)), wantStage: "tls", wantHint: hintTLS},
+		{name: "HTTP 401", err: &httpStatusError{StatusCode: http.StatusUnauthorized}, wantStage: "auth", wantHint: hintAuth},
+		{name: "HTTP 403", err: fmt.Errorf("traces export: %w", &httpStatusError{StatusCode: http.StatusForbidden}), wantStage: "auth", wantHint: hintAuth},
+		{name: "HTTP 404", err: &httpStatusError{StatusCode: http.StatusNotFound}, wantStage: "export", wantHint: "no OTLP traces path"},
+		{name: "HTTP 500", err: &httpStatusError{StatusCode: http.StatusInternalServerError, Body: "401 in body"}, wantStage: "export", wantHint: "check otel-exporter-otlp-endpoint"},
+		{name: "deadline", err: fmt.Errorf("traces export: %w", context.DeadlineExceeded), wantStage: "export", wantHint: hintTimeout},
+		{name: "network timeout", err: &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, wantStage: "export", wantHint: hintTimeout},
+		{name: "gRPC unauthenticated", err: status.Error(codes.Unauthenticated, "invalid API key"), wantStage: "auth", wantHint: hintAuth},
+		{name: "gRPC permission denied", err: status.Error(codes.PermissionDenied, "403"), wantStage: "auth", wantHint: hintAuth},
+		{name: "gRPC unimplemented", err: status.Error(codes.Unimplemented, "unknown service"), wantStage: "export", wantHint: "does not serve OTLP traces over gRPC"},
+		{name: "gRPC deadline", err: status.Error(codes.DeadlineExceeded, "context deadline exceeded"), wantStage: "export", wantHint: hintTimeout},
+		{name: "gRPC DNS", err: status.Error(codes.Unavailable, `name resolver error: produced zero addresses`), wantStage: "dns", wantHint: hintDNS},
+		{name: "gRPC TLS", err: status.Error(codes.Unavailable, `connection error: desc = "transport: authentication handshake failed: tls: failed to verify certificate: x509: certificate signed by unknown authority"`), wantStage: "tls", wantHint: hintTLS},
+		{name: "gRPC refused", err: status.Error(codes.Unavailable, `connection error: desc = "transport: Error while dialing: dial tcp 127.0.0.1:4317: connect: connection refused"`), wantStage: "export", wantHint: hintRefused},
+		{name: "gRPC message of other code", err: status.Error(codes.Internal, "connection refused by handler"), wantStage: "export", wantHint: "check otel-exporter-otlp-endpoint"},
+		{name: "unknown", err: errors.New("boom"), wantStage: "export", wantHint: "check otel-exporter-otlp-endpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *ConnectivityError
+			if !errors.As(classifyExportError(tt.err), &got) {
+				t.Fatalf("classifyExportError() did not return a ConnectivityError")
+			}
+			if got.Stage != tt.wantStage || !strings.Contains(got.Hint, tt.wantHint) {
+				t.Errorf("classifyExportError() = %s: %q, want %s: %q", got.Stage, got.Hint, tt.wantStage, tt.wantHint)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("classifyExportError() does not wrap %v", tt.err)
+			}
+		})
+	}
+}
+
+func TestCheckConnectivity(t *testing.T) {
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(authServer.Close)
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(tlsServer.Close)
+
+	// A port that was just released refuses connections.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		name      string
+		cfg       ExporterConfig
+		wantStage string
+		wantHint  string
+	}{
+		{name: "reachable", cfg: ExporterConfig{Endpoint: authServer.URL, Protocol: ProtocolHTTPProtobuf, Headers: map[string]string{"Authorization": "Bearer secret"}}},
+		{name: "auth", cfg: ExporterConfig{Endpoint: authServer.URL, Protocol: ProtocolHTTPProtobuf}, wantStage: "auth", wantHint: hintAuth},
+		{name: "untrusted certificate", cfg: ExporterConfig{Endpoint: tlsServer.URL, Protocol: ProtocolHTTPProtobuf}, wantStage: "tls", wantHint: hintTLS},
+		{name: "refused", cfg: ExporterConfig{Endpoint: "http://" + closedAddr, Protocol: ProtocolHTTPProtobuf}, wantStage: "export", wantHint: hintRefused},
+		{name: "gRPC refused", cfg: ExporterConfig{Endpoint: closedAddr, Protocol: ProtocolGRPC, Insecure: true}, wantStage: "export", wantHint: hintRefused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if err := cfg.Normalize(); err != nil {
+				t.Fatalf("Normalize() error: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := CheckConnectivity(ctx, cfg)
+			if tt.wantStage == "" {
+				if err != nil {
+					t.Fatalf("CheckConnectivity() error: %v", err)
+				}
+				return
+			}
+			var connErr *ConnectivityError
+			if !errors.As(err, &connErr) {
+				t.Fatalf("CheckConnectivity() = %v, want a ConnectivityError", err)
+			}
+			if connErr.Stage != tt.wantStage || connErr.Hint != tt.wantHint {
+				t.Errorf("CheckConnectivity() = %s: %q, want %s: %q (%v)", connErr.Stage, connErr.Hint, tt.wantStage, tt.wantHint, err)
+			}
+		})
+	}
+}
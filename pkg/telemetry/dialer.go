@@ -0,0 +1,225 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// unixScheme prefixes endpoints of collectors listening on a Unix domain
+// socket, e.g. unix:///var/run/otelcol.sock.
+const unixScheme = "unix:"
+
+// DialFunc opens a connection to address on the named network, like
+// net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// IsUnixEndpoint reports whether endpoint is a Unix domain socket.
+func IsUnixEndpoint(endpoint string) bool {
+	return strings.HasPrefix(strings.ToLower(endpoint), unixScheme)
+}
+
+// normalizeUnixEndpoint rewrites a Unix socket endpoint to the unix:///path or
+// unix:path form gRPC accepts.
+func normalizeUnixEndpoint(endpoint string) (string, error) {
+	path := strings.TrimPrefix(endpoint[len(unixScheme):], "//")
+	if path == "" {
+		return "", fmt.Errorf("invalid OTLP endpoint %q: missing socket path, expected unix:///path/to/socket", endpoint)
+	}
+	if filepath.IsAbs(path) {
+		return unixScheme + "//" + path, nil
+	}
+	return unixScheme + path, nil
+}
+
+// socketPath returns the path of a Unix socket endpoint, or "".
+func (c ExporterConfig) socketPath() string {
+	if !IsUnixEndpoint(c.Endpoint) {
+		return ""
+	}
+	return strings.TrimPrefix(c.Endpoint[len(unixScheme):], "//")
+}
+
+// customDialer reports whether connections are opened by dial rather than by
+// the OTLP exporters themselves.
+func (c ExporterConfig) customDialer() bool {
+	return c.Dialer != nil || c.socketPath() != ""
+}
+
+// dial opens a connection with Dialer, or a net.Dialer when not set. Unix socket
+// endpoints always connect to their socket, whatever the address.
+func (c ExporterConfig) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	if path := c.socketPath(); path != "" {
+		network, address = "unix", path
+	}
+	if c.Dialer != nil {
+		return c.Dialer(ctx, network, address)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, network, address)
+}
+
+// grpcTarget returns the gRPC target of the endpoint. The endpoint of a Dialer
+// or a proxy goes through the passthrough resolver, so that the Dialer or the
+// proxy resolves it rather than gRPC.
+func (c ExporterConfig) grpcTarget() string {
+	if c.socketPath() != "" {
+		return c.Endpoint
+	}
+	if c.Dialer == nil {
+		if proxyURL, err := c.grpcProxyURL(); err != nil || proxyURL == nil {
+			return c.Endpoint
+		}
+	}
+	return "passthrough:///" + c.grpcHostPort()
+}
+
+// httpProxy returns the proxy of the OTLP/HTTP exporters and a function that
+// stops it. The exporters cannot be given a dialer, so with a custom dialer
+// they are pointed at a proxy on the loopback interface that dials through it.
+func (c ExporterConfig) httpProxy() (func(*http.Request) (*url.URL, error), func(), error) {
+	if !c.customDialer() {
+		proxy, err := c.proxy()
+		return proxy, func() {}, err
+	}
+
+	p, err := startDialerProxy(c.dial)
+	if err != nil {
+		return nil, nil, err
+	}
+	return http.ProxyURL(&url.URL{Scheme: "http", Host: p.listener.Addr().String()}), p.close, nil
+}
+
+// dialerProxy is an HTTP proxy that opens its upstream connections with dial,
+// tunnelling CONNECT requests and forwarding plain HTTP requests.
+type dialerProxy struct {
+	listener  net.Listener
+	server    *http.Server
+	transport *http.Transport
+	dial      DialFunc
+}
+
+func startDialerProxy(dial DialFunc) (*dialerProxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start dialer proxy: %w", err)
+	}
+	p := &dialerProxy{
+		listener:  listener,
+		transport: &http.Transport{DialContext: dial},
+		dial:      dial,
+	}
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = p.server.Serve(listener) }()
+	return p, nil
+}
+
+func (p *dialerProxy) close() {
+	_ = p.server.Close()
+	p.transport.CloseIdleConnections()
+}
+
+func (p *dialerProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+
+	req := r.Clone(r.Context())
+	req.RequestURI = ""
+	req.Header.Del("Proxy-Connection")
+	req.Header.Del("Proxy-Authorization")
+	resp, err := p.transport.RoundTrip(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+func (p *dialerProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := p.dial(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		conn.Close()
+		upstream.Close()
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(upstream, &bufferedConn{Conn: conn, r: buffered.Reader})
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(conn, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+	conn.Close()
+	upstream.Close()
+}
+
+// The exporters below stop the dialer proxy of their OTLP/HTTP exporter when
+// they are shut down.
+
+type stopProxyTraceClient struct {
+	otlptrace.Client
+	stopProxy func()
+}
+
+func (c *stopProxyTraceClient) Stop(ctx context.Context) error {
+	defer c.stopProxy()
+	return c.Client.Stop(ctx)
+}
+
+type stopProxyMetricExporter struct {
+	sdkmetric.Exporter
+	stopProxy func()
+}
+
+func (e *stopProxyMetricExporter) Shutdown(ctx context.Context) error {
+	defer e.stopProxy()
+	return e.Exporter.Shutdown(ctx)
+}
+
+type stopProxyLogExporter struct {
+	sdklog.Exporter
+	stopProxy func()
+}
+
+func (e *stopProxyLogExporter) Shutdown(ctx context.Context) error {
+	defer e.stopProxy()
+	return e.Exporter.Shutdown(ctx)
+}
@@ -0,0 +1,201 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// testCollector records the names of the spans it receives over gRPC and HTTP.
+type testCollector struct {
+	coltracepb.UnimplementedTraceServiceServer
+
+	mu    sync.Mutex
+	spans []string
+}
+
+func (c *testCollector) Export(_ context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	c.record(req)
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func (c *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.record(&req)
+}
+
+func (c *testCollector) record(req *coltracepb.ExportTraceServiceRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				c.spans = append(c.spans, span.Name)
+			}
+		}
+	}
+}
+
+func (c *testCollector) Spans() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.spans...)
+}
+
+// serveCollector serves a testCollector on listener over protocol.
+func serveCollector(t *testing.T, listener net.Listener, protocol string) *testCollector {
+	t.Helper()
+
+	c := &testCollector{}
+	if protocol == ProtocolGRPC {
+		server := grpc.NewServer()
+		coltracepb.RegisterTraceServiceServer(server, c)
+		go func() { _ = server.Serve(listener) }()
+		t.Cleanup(server.Stop)
+		return c
+	}
+	server := &http.Server{Handler: c}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+	return c
+}
+
+// testSocketPath returns the path of a Unix socket in a short temporary
+// directory, as socket paths are limited to about 100 bytes.
+func testSocketPath(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "otel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "collector.sock")
+}
+
+// exportTestSpan exports a span named build with cfg.
+func exportTestSpan(t *testing.T, cfg ExporterConfig) error {
+	t.Helper()
+
+	if err := cfg.Normalize(); err != nil {
+		t.Fatalf("Normalize() error: %v", err)
+	}
+	exp, err := NewSpanExporter(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exp.ExportSpans(context.Background(), tracetest.SpanStubs{{Name: "build"}}.Snapshots())
+	if shutdownErr := exp.Shutdown(context.Background()); shutdownErr != nil {
+		t.Errorf("Shutdown() error: %v", shutdownErr)
+	}
+	return err
+}
+
+func TestExportToUnixSocket(t *testing.T) {
+	for _, protocol := range []string{ProtocolGRPC, ProtocolHTTPProtobuf} {
+		t.Run(protocol, func(t *testing.T) {
+			path := testSocketPath(t)
+			listener, err := net.Listen("unix", path)
+			if err != nil {
+				t.Skipf("Unix sockets are not supported: %v", err)
+			}
+			collector := serveCollector(t, listener, protocol)
+
+			if err := exportTestSpan(t, ExporterConfig{Endpoint: "unix://" + path, Protocol: protocol}); err != nil {
+				t.Fatalf("export error: %v", err)
+			}
+			if got := collector.Spans(); len(got) != 1 || got[0] != "build" {
+				t.Errorf("collector received %q, want [build]", got)
+			}
+		})
+	}
+}
+
+func TestExportWithDialer(t *testing.T) {
+	tests := []struct {
+		protocol string
+		endpoint string
+	}{
+		{protocol: ProtocolGRPC, endpoint: "collector.invalid:4317"},
+		{protocol: ProtocolHTTPProtobuf, endpoint: "http://collector.invalid:4318"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.protocol, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector := serveCollector(t, listener, tt.protocol)
+
+			var dialed atomic.Int32
+			var mu sync.Mutex
+			var addresses []string
+			dialer := func(ctx context.Context, network, address string) (net.Conn, error) {
+				dialed.Add(1)
+				mu.Lock()
+				addresses = append(addresses, address)
+				mu.Unlock()
+				var d net.Dialer
+				return d.DialContext(ctx, "tcp", listener.Addr().String())
+			}
+
+			// The endpoint does not resolve, so the span only arrives if the
+			// dialer opened the connection.
+			if err := exportTestSpan(t, ExporterConfig{Endpoint: tt.endpoint, Protocol: tt.protocol, Insecure: true, Dialer: dialer}); err != nil {
+				t.Fatalf("export error: %v", err)
+			}
+			if got := collector.Spans(); len(got) != 1 || got[0] != "build" {
+				t.Errorf("collector received %q, want [build]", got)
+			}
+			if dialed.Load() == 0 {
+				t.Fatal("dialer was not used")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, address := range addresses {
+				if address != "collector.invalid:4317" && address != "collector.invalid:4318" {
+					t.Errorf("dialer was asked for %q, want the endpoint address", address)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeUnixEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+		wantErr  bool
+	}{
+		{endpoint: "unix:///var/run/otelcol.sock", want: "unix:///var/run/otelcol.sock"},
+		{endpoint: "unix:/var/run/otelcol.sock", want: "unix:///var/run/otelcol.sock"},
+		{endpoint: "unix:otelcol.sock", want: "unix:otelcol.sock"},
+		{endpoint: "unix://", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeUnixEndpoint(tt.endpoint)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeUnixEndpoint(%q) = %q, %v, want %q, error %t", tt.endpoint, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SpanEvent is a milestone of a job, such as the start of its tests, recorded as
+// an event on the job span.
+type SpanEvent struct {
+	Name       string
+	Timestamp  time.Time
+	Attributes []attribute.KeyValue
+}
+
+// ParseSpanEvents parses events separated by newlines, each of the form
+// name|timestamp|key=value,..., e.g.
+// tests started|2024-05-01T10:00:00Z|suite=unit. The timestamp is in any
+// format ParseTimestamp accepts. The attributes are optional, and typed like
+// TypedAttribute.
+func ParseSpanEvents(input string) ([]SpanEvent, error) {
+	var events []SpanEvent
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, "|", 3)
+		name := strings.TrimSpace(fields[0])
+		if len(fields) < 2 || name == "" {
+			return nil, fmt.Errorf("invalid span event %q, expected name|timestamp|key=value,...", line)
+		}
+		timestamp, err := ParseTimestamp(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid span event %q: %w", name, err)
+		}
+
+		event := SpanEvent{Name: name, Timestamp: timestamp}
+		if len(fields) == 3 {
+			pairs, errs := ParseKeyValuePairsWithErrors(fields[2])
+			if len(errs) > 0 {
+				return nil, fmt.Errorf("invalid span event %q: %w", name, errors.Join(errs...))
+			}
+			if event.Attributes, err = TypedAttributes(pairs); err != nil {
+				return nil, fmt.Errorf("invalid span event %q: %w", name, err)
+			}
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,173 @@
+package telemetry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnsureEndpointPort(t *testing.T) {
+	tests := []struct {
+		name       string
+		endpoint   string
+		autoAppend bool
+		want       string
+		wantErr    bool
+	}{
+		{name: "host without port", endpoint: "host", autoAppend: true, want: "host:4317"},
+		{name: "host with port", endpoint: "host:4317", autoAppend: true, want: "host:4317"},
+		{name: "host with other port", endpoint: "host:55680", want: "host:55680"},
+		{name: "scheme without port", endpoint: "http://host", autoAppend: true, want: "http://host:4317"},
+		{name: "scheme with port", endpoint: "https://host:4317", want: "https://host:4317"},
+		{name: "IPv6 without port", endpoint: "[::1]", autoAppend: true, want: "[::1]:4317"},
+		{name: "IPv6 with port", endpoint: "[::1]:4317", want: "[::1]:4317"},
+		{name: "no port without auto-append-port", endpoint: "host", wantErr: true},
+		{name: "IPv6 without port without auto-append-port", endpoint: "[::1]", wantErr: true},
+		{name: "empty", endpoint: "", autoAppend: true, wantErr: true},
+		{name: "https defaults to 443", endpoint: "https://host", want: "https://host:443"},
+		{name: "https IPv6 defaults to 443", endpoint: "https://[::1]", autoAppend: true, want: "https://[::1]:443"},
+		{name: "path without port", endpoint: "host/v1/traces", autoAppend: true, wantErr: true},
+		{name: "scheme and path without port", endpoint: "http://host/v1/traces", autoAppend: true, wantErr: true},
+		{name: "path with port", endpoint: "http://host:4317/v1/traces", want: "http://host:4317/v1/traces"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EnsureEndpointPort(tt.endpoint, tt.autoAppend)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("EnsureEndpointPort(%q, %t) = %q, want error", tt.endpoint, tt.autoAppend, got)
+				}
+				if strings.Contains(err.Error(), "did you mean") && (tt.endpoint == "" || strings.Contains(tt.endpoint, "/")) {
+					t.Errorf("EnsureEndpointPort(%q, %t) error %q suggests an endpoint", tt.endpoint, tt.autoAppend, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EnsureEndpointPort(%q, %t) error: %v", tt.endpoint, tt.autoAppend, err)
+			}
+			if got != tt.want {
+				t.Errorf("EnsureEndpointPort(%q, %t) = %q, want %q", tt.endpoint, tt.autoAppend, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeGRPCEndpoint(t *testing.T) {
+	tests := []struct {
+		name         string
+		endpoint     string
+		insecure     bool
+		wantEndpoint string
+		wantInsecure bool
+	}{
+		{name: "host and port", endpoint: "collector:4317", wantEndpoint: "collector:4317"},
+		{name: "insecure host and port", endpoint: "collector:4317", insecure: true, wantEndpoint: "collector:4317", wantInsecure: true},
+		{name: "http scheme", endpoint: "http://collector:4317", wantEndpoint: "collector:4317", wantInsecure: true},
+		{name: "https scheme", endpoint: "https://collector:4317/", insecure: true, wantEndpoint: "collector:4317"},
+		{name: "https scheme with path", endpoint: "https://collector:4317/v1/traces", wantEndpoint: "collector:4317"},
+		{name: "path", endpoint: "collector:4317/v1/traces", wantEndpoint: "collector:4317"},
+		{name: "trailing slash", endpoint: "collector:4317/", insecure: true, wantEndpoint: "collector:4317", wantInsecure: true},
+		{name: "IPv6 with path", endpoint: "[::1]:4317/v1/traces", wantEndpoint: "[::1]:4317"},
+		{name: "unix socket", endpoint: "unix:///run/otelcol.sock", wantEndpoint: "unix:///run/otelcol.sock", wantInsecure: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ExporterConfig{Endpoint: tt.endpoint, Insecure: tt.insecure}
+			if err := cfg.Normalize(); err != nil {
+				t.Fatalf("Normalize() error: %v", err)
+			}
+			if cfg.Endpoint != tt.wantEndpoint || cfg.Insecure != tt.wantInsecure {
+				t.Errorf("Normalize() = %q insecure=%t, want %q insecure=%t", cfg.Endpoint, cfg.Insecure, tt.wantEndpoint, tt.wantInsecure)
+			}
+		})
+	}
+}
+
+func TestNormalizeProtocol(t *testing.T) {
+	tests := []struct {
+		protocol string
+		want     string
+		wantErr  bool
+	}{
+		{protocol: "", want: ProtocolGRPC},
+		{protocol: "grpc", want: ProtocolGRPC},
+		{protocol: " GRPC ", want: ProtocolGRPC},
+		{protocol: "http", want: ProtocolHTTPProtobuf},
+		{protocol: "http/protobuf", want: ProtocolHTTPProtobuf},
+		{protocol: "http/json", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.protocol, func(t *testing.T) {
+			got, err := NormalizeProtocol(tt.protocol)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NormalizeProtocol(%q) = %q, want error", tt.protocol, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeProtocol(%q) error: %v", tt.protocol, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeProtocol(%q) = %q, want %q", tt.protocol, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPSignalURL(t *testing.T) {
+	tests := []struct {
+		endpoint   string
+		signalPath string
+		want       string
+		wantErr    bool
+	}{
+		{endpoint: "https://collector.example.com:4318", signalPath: defaultOTLPTracesPath, want: "https://collector.example.com:4318/v1/traces"},
+		{endpoint: "https://collector.example.com/", signalPath: defaultOTLPTracesPath, want: "https://collector.example.com/v1/traces"},
+		{endpoint: "https://collector.example.com/v1/traces", signalPath: defaultOTLPTracesPath, want: "https://collector.example.com/v1/traces"},
+		{endpoint: "https://collector.example.com/v1/traces", signalPath: defaultOTLPMetricsPath, want: "https://collector.example.com/v1/metrics"},
+		{endpoint: "https://collector.example.com/otlp", signalPath: defaultOTLPMetricsPath, want: "https://collector.example.com/otlp/v1/metrics"},
+		{endpoint: "collector:4318", signalPath: defaultOTLPMetricsPath, want: "//collector:4318/v1/metrics"},
+		{endpoint: "https://", signalPath: defaultOTLPTracesPath, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint+tt.signalPath, func(t *testing.T) {
+			got, err := HTTPSignalURL(tt.endpoint, tt.signalPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("HTTPSignalURL(%q, %q) = %q, want error", tt.endpoint, tt.signalPath, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HTTPSignalURL(%q, %q) error: %v", tt.endpoint, tt.signalPath, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("HTTPSignalURL(%q, %q) = %q, want %q", tt.endpoint, tt.signalPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTracesURL(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ExporterConfig
+		want string
+	}{
+		{name: "base URL", cfg: ExporterConfig{Endpoint: "https://collector.example.com/otlp"}, want: "https://collector.example.com/otlp/v1/traces"},
+		{name: "signal URL", cfg: ExporterConfig{Endpoint: "https://collector.example.com/otlp", SignalURL: true}, want: "https://collector.example.com/otlp"},
+		{name: "signal URL without scheme", cfg: ExporterConfig{Endpoint: "collector:4318/ingest/traces", SignalURL: true}, want: "//collector:4318/ingest/traces"},
+		{name: "signal URL of a Unix socket", cfg: ExporterConfig{Endpoint: "unix:///run/otel.sock", SignalURL: true}, want: "http://localhost/v1/traces"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.tracesURL()
+			if err != nil {
+				t.Fatalf("tracesURL() error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("tracesURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// DefaultFilePath is the file the file exporter writes to when no path is
+// configured.
+const DefaultFilePath = "otel-traces.json"
+
+// fileClient collects the spans of a file exporter and writes them as a single
+// OTLP JSON request when it is stopped, so the file can be forwarded as is.
+type fileClient struct {
+	path string
+
+	mu            sync.Mutex
+	resourceSpans []*tracepb.ResourceSpans
+}
+
+func newFileSpanExporter(ctx context.Context, cfg ExporterConfig) (*otlptrace.Exporter, error) {
+	path := cfg.FilePath
+	if path == "" {
+		path = DefaultFilePath
+	}
+	return otlptrace.New(ctx, &fileClient{path: path})
+}
+
+func (c *fileClient) Start(context.Context) error {
+	return nil
+}
+
+func (c *fileClient) UploadTraces(_ context.Context, resourceSpans []*tracepb.ResourceSpans) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resourceSpans = append(c.resourceSpans, resourceSpans...)
+	return nil
+}
+
+func (c *fileClient) Stop(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := MarshalTracesJSON(c.resourceSpans)
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write spans: %w", err)
+	}
+	return nil
+}
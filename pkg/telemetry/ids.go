@@ -0,0 +1,79 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	traceIDKey struct{}
+	spanIDKey  struct{}
+)
+
+// IDGenerator generates random ids, except for spans started with a context
+// from ContextWithTraceID or ContextWithSpanID, which use the given ids.
+type IDGenerator struct{}
+
+// ContextWithTraceID returns a context that makes IDGenerator use traceID for
+// the next root span started with it.
+func ContextWithTraceID(ctx context.Context, traceID trace.TraceID) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// ContextWithSpanID returns a context that makes IDGenerator use spanID for the
+// next span started with it. The context must not be used to start other
+// spans.
+func ContextWithSpanID(ctx context.Context, spanID trace.SpanID) context.Context {
+	return context.WithValue(ctx, spanIDKey{}, spanID)
+}
+
+func (IDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	traceID, ok := ctx.Value(traceIDKey{}).(trace.TraceID)
+	if !ok || !traceID.IsValid() {
+		_, _ = rand.Read(traceID[:])
+	}
+	return traceID, IDGenerator{}.NewSpanID(ctx, traceID)
+}
+
+func (IDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	spanID, _ := ctx.Value(spanIDKey{}).(trace.SpanID)
+	for !spanID.IsValid() {
+		_, _ = rand.Read(spanID[:])
+	}
+	return spanID
+}
+
+// RunTraceID derives the trace id of a workflow run the same way as the
+// OpenTelemetry Collector GitHub Actions receiver, so spans line up with it.
+func RunTraceID(runID, runAttempt int64) trace.TraceID {
+	var traceID trace.TraceID
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d%dt", runID, runAttempt)))
+	copy(traceID[:], sum[:16])
+	return traceID
+}
+
+// PipelineTraceID derives the trace id of a pipeline run of another CI system
+// from its id, so that all jobs of the run share a trace.
+func PipelineTraceID(system, runID string) trace.TraceID {
+	var traceID trace.TraceID
+	sum := sha256.Sum256([]byte(system + "/" + runID))
+	copy(traceID[:], sum[:16])
+	return traceID
+}
+
+// JobSpanID derives a span id from the identity of a job, so that exporting the
+// same job again, e.g. when the action is retried, produces the same span and
+// backends can deduplicate it.
+func JobSpanID(repository string, runID, runAttempt int64, jobName string) trace.SpanID {
+	var spanID trace.SpanID
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d/%d/%s", repository, runID, runAttempt, jobName)))
+	copy(spanID[:], sum[:8])
+	if !spanID.IsValid() {
+		spanID[7] = 1
+	}
+	return spanID
+}
@@ -0,0 +1,31 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIDGeneratorUsesContextTraceID(t *testing.T) {
+	traceID := RunTraceID(123456789, 2)
+
+	got, spanID := IDGenerator{}.NewIDs(ContextWithTraceID(context.Background(), traceID))
+	if got != traceID {
+		t.Errorf("NewIDs() trace id = %s, want %s", got, traceID)
+	}
+	if !spanID.IsValid() {
+		t.Error("NewIDs() returned an invalid span id")
+	}
+
+	if got, _ := (IDGenerator{}).NewIDs(context.Background()); !got.IsValid() || got == traceID {
+		t.Errorf("NewIDs() without a context trace id = %s, want a new random trace id", got)
+	}
+}
+
+func TestRunTraceID(t *testing.T) {
+	if got, want := RunTraceID(123456789, 2).String(), "e91f2b9d927f576c050158b6ebffadd1"; got != want {
+		t.Errorf("RunTraceID(123456789, 2) = %s, want %s", got, want)
+	}
+	if RunTraceID(123456789, 1) == RunTraceID(123456789, 2) {
+		t.Error("RunTraceID() returned the same trace id for different run attempts")
+	}
+}
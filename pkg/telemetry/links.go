@@ -0,0 +1,46 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ParseSpanLinks parses links to related traces, separated by commas or
+// newlines. Each link is a traceparent optionally followed by space-separated
+// key=value link attributes, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 link.type=deploy.
+// Attribute values are typed like TypedAttribute.
+func ParseSpanLinks(input string) ([]trace.Link, error) {
+	var links []trace.Link
+	for _, line := range strings.Split(input, "\n") {
+		for _, entry := range splitPairs(line) {
+			fields := strings.Fields(entry)
+			if len(fields) == 0 {
+				continue
+			}
+
+			spanContext, err := ParseTraceparent(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid span link %q: %w", entry, err)
+			}
+
+			pairs := make(map[string]string, len(fields)-1)
+			for _, field := range fields[1:] {
+				key, value, ok := strings.Cut(field, "=")
+				if !ok || key == "" {
+					return nil, fmt.Errorf("invalid span link %q: attribute %q is not key=value", entry, field)
+				}
+				pairs[key] = value
+			}
+			attributes, err := TypedAttributes(pairs)
+			if err != nil {
+				return nil, fmt.Errorf("invalid span link %q: %w", entry, err)
+			}
+
+			links = append(links, trace.Link{SpanContext: spanContext, Attributes: attributes})
+		}
+	}
+	return links, nil
+}
@@ -0,0 +1,39 @@
+package telemetry
+
+import "testing"
+
+func TestParseSpanLinks(t *testing.T) {
+	links, err := ParseSpanLinks("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 link.type=deploy attempt=2,\n\n00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 2 {
+		t.Fatalf("parsed %d links, want 2", len(links))
+	}
+	if got := links[0].SpanContext.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("link 0 span id = %s, want 00f067aa0ba902b7", got)
+	}
+	attributes := attributeMap(links[0].Attributes)
+	if attributes["link.type"].AsString() != "deploy" || attributes["attempt"].AsInt64() != 2 {
+		t.Errorf("link 0 attributes = %v, want link.type=deploy and attempt=2", links[0].Attributes)
+	}
+	if got := links[1].SpanContext.TraceID().String(); got != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("link 1 trace id = %s, want 0af7651916cd43dd8448eb211c80319c", got)
+	}
+	if len(links[1].Attributes) != 0 {
+		t.Errorf("link 1 attributes = %v, want none", links[1].Attributes)
+	}
+}
+
+func TestParseSpanLinksErrors(t *testing.T) {
+	for _, input := range []string{
+		"not-a-traceparent",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 link.type",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 =deploy",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 attempt:int=two",
+	} {
+		if _, err := ParseSpanLinks(input); err == nil {
+			t.Errorf("ParseSpanLinks(%q) succeeded, want error", input)
+		}
+	}
+}
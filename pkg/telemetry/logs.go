@@ -0,0 +1,258 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+const defaultOTLPLogsPath = "/v1/logs"
+
+// LogLine is a line of a job log.
+type LogLine struct {
+	Timestamp time.Time
+	Body      string
+}
+
+// StepLog is the log output of a step, correlated with the span it is exported
+// under.
+type StepLog struct {
+	SpanContext trace.SpanContext
+	Step        Step
+	Lines       []LogLine
+}
+
+// NewLogExporter creates a log exporter for the configured type and protocol.
+func NewLogExporter(ctx context.Context, cfg ExporterConfig) (sdklog.Exporter, error) {
+	if cfg.Type == ExporterTypeFile {
+		return nil, errors.New("the file exporter only supports traces")
+	}
+	if cfg.Type == ExporterTypeConsole {
+		return stdoutlog.New(stdoutlog.WithWriter(cfg.consoleWriter()), stdoutlog.WithPrettyPrint())
+	}
+
+	if cfg.Protocol == ProtocolHTTPProtobuf {
+		endpointURL, err := HTTPSignalURL(cfg.Endpoint, defaultOTLPLogsPath)
+		if err != nil {
+			return nil, err
+		}
+
+		proxy, stopProxy, err := cfg.httpProxy()
+		if err != nil {
+			return nil, err
+		}
+		clientOptions := []otlploghttp.Option{
+			otlploghttp.WithHeaders(cfg.Headers),
+			otlploghttp.WithProxy(proxy),
+		}
+		if endpointURL.Scheme == "" {
+			clientOptions = append(clientOptions,
+				otlploghttp.WithEndpoint(endpointURL.Host),
+				otlploghttp.WithURLPath(endpointURL.Path),
+			)
+		} else {
+			clientOptions = append(clientOptions, otlploghttp.WithEndpointURL(endpointURL.String()))
+		}
+		if cfg.Insecure {
+			clientOptions = append(clientOptions, otlploghttp.WithInsecure())
+		} else if tlsConfig, err := cfg.TLSConfig(); err != nil {
+			stopProxy()
+			return nil, err
+		} else if tlsConfig != nil {
+			clientOptions = append(clientOptions, otlploghttp.WithTLSClientConfig(tlsConfig))
+		}
+		if cfg.Timeout > 0 {
+			clientOptions = append(clientOptions, otlploghttp.WithTimeout(cfg.Timeout))
+		}
+		if cfg.Compression == CompressionGzip {
+			clientOptions = append(clientOptions, otlploghttp.WithCompression(otlploghttp.GzipCompression))
+		}
+		if r := cfg.Retry; r != nil {
+			r := r.withDefaults()
+			clientOptions = append(clientOptions, otlploghttp.WithRetry(otlploghttp.RetryConfig{
+				Enabled:         r.Enabled,
+				InitialInterval: r.InitialInterval,
+				MaxInterval:     r.MaxInterval,
+				MaxElapsedTime:  r.MaxElapsedTime,
+			}))
+		}
+		exp, err := otlploghttp.New(ctx, clientOptions...)
+		if err != nil {
+			stopProxy()
+			return nil, err
+		}
+		return &stopProxyLogExporter{Exporter: exp, stopProxy: stopProxy}, nil
+	}
+
+	dialOption, err := cfg.grpcDialOption()
+	if err != nil {
+		return nil, err
+	}
+	clientOptions := []otlploggrpc.Option{
+		otlploggrpc.WithEndpoint(cfg.grpcTarget()),
+		otlploggrpc.WithHeaders(cfg.Headers),
+		otlploggrpc.WithDialOption(dialOption),
+	}
+	if cfg.Insecure {
+		clientOptions = append(clientOptions, otlploggrpc.WithInsecure())
+	} else if tlsConfig, err := cfg.TLSConfig(); err != nil {
+		return nil, err
+	} else if tlsConfig != nil {
+		clientOptions = append(clientOptions, otlploggrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	if cfg.Timeout > 0 {
+		clientOptions = append(clientOptions, otlploggrpc.WithTimeout(cfg.Timeout))
+	}
+	if cfg.Compression == CompressionGzip {
+		clientOptions = append(clientOptions, otlploggrpc.WithCompressor(CompressionGzip))
+	}
+	if r := cfg.Retry; r != nil {
+		r := r.withDefaults()
+		clientOptions = append(clientOptions, otlploggrpc.WithRetry(otlploggrpc.RetryConfig{
+			Enabled:         r.Enabled,
+			InitialInterval: r.InitialInterval,
+			MaxInterval:     r.MaxInterval,
+			MaxElapsedTime:  r.MaxElapsedTime,
+		}))
+	}
+	return otlploggrpc.New(ctx, clientOptions...)
+}
+
+// ParseJobLog parses a GitHub Actions job log, in which every line starts with
+// an RFC 3339 timestamp. Lines without a timestamp continue the previous line.
+func ParseJobLog(r io.Reader) ([]LogLine, error) {
+	var lines []LogLine
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimPrefix(scanner.Text(), "\ufeff")
+		if timestamp, body, ok := strings.Cut(text, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+				lines = append(lines, LogLine{Timestamp: t, Body: body})
+				continue
+			}
+		}
+		if len(lines) > 0 {
+			lines[len(lines)-1].Body += "\n" + text
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// StepLogLines splits the lines of a job log between its steps, returning the
+// lines of each step at its index. Step times are only precise to the second,
+// so each step owns the half-open window from its start to the start of the
+// next step that started later, and a line goes to at most one step. Steps
+// that started in the same second as the step before them own no lines, as
+// their lines cannot be told apart. The last window ends with the second the
+// job's steps completed in.
+func StepLogLines(lines []LogLine, steps []Step) [][]LogLine {
+	stepLines := make([][]LogLine, len(steps))
+	var prevStart time.Time
+	for i, step := range steps {
+		if step.StartedAt == nil {
+			continue
+		}
+		start := step.StartedAt.Truncate(time.Second)
+		if start.Equal(prevStart) {
+			continue
+		}
+		prevStart = start
+
+		end, open := stepLogEnd(steps[i:], start)
+		for _, line := range lines {
+			if line.Timestamp.Before(start) || (!open && !line.Timestamp.Before(end)) {
+				continue
+			}
+			stepLines[i] = append(stepLines[i], line)
+		}
+	}
+	return stepLines
+}
+
+// stepLogEnd returns the end of the log window of the first of steps, which
+// started at start: the start of the next step that started later or, without
+// one, the second after the last completion. open is set when a step has not
+// completed.
+func stepLogEnd(steps []Step, start time.Time) (end time.Time, open bool) {
+	for _, step := range steps[1:] {
+		if step.StartedAt != nil && step.StartedAt.Truncate(time.Second).After(start) {
+			return step.StartedAt.Truncate(time.Second), false
+		}
+	}
+	for _, step := range steps {
+		if step.StartedAt == nil {
+			continue
+		}
+		if step.CompletedAt == nil {
+			return time.Time{}, true
+		}
+		if completed := step.CompletedAt.Truncate(time.Second).Add(time.Second); completed.After(end) {
+			end = completed
+		}
+	}
+	return end, false
+}
+
+// ExportStepLogs exports the step logs as OTLP log records through a
+// short-lived logger provider. Each record carries the trace and span id of its
+// step, so it can be looked up from the trace.
+func ExportStepLogs(ctx context.Context, cfg ExporterConfig, res *resource.Resource, logs []StepLog) error {
+	exp, err := NewLogExporter(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize log exporter: %w", err)
+	}
+
+	loggerProvider := sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exp)),
+		sdklog.WithResource(res),
+	)
+	logger := loggerProvider.Logger(InstrumentationName)
+
+	for _, stepLog := range logs {
+		stepCtx := trace.ContextWithSpanContext(ctx, stepLog.SpanContext)
+		for _, line := range stepLog.Lines {
+			var record log.Record
+			record.SetTimestamp(line.Timestamp)
+			record.SetObservedTimestamp(line.Timestamp)
+			severity, severityText := logLineSeverity(line.Body)
+			record.SetSeverity(severity)
+			record.SetSeverityText(severityText)
+			record.SetBody(log.StringValue(line.Body))
+			record.AddAttributes(
+				log.String("ci.github.workflow.job.step.name", stepLog.Step.Name),
+				log.Int64("ci.github.workflow.job.step.number", stepLog.Step.Number),
+			)
+			logger.Emit(stepCtx, record)
+		}
+	}
+
+	if err := loggerProvider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down logger provider: %w", err)
+	}
+	return nil
+}
+
+func logLineSeverity(body string) (log.Severity, string) {
+	switch {
+	case strings.HasPrefix(body, "##[error]"):
+		return log.SeverityError, "ERROR"
+	case strings.HasPrefix(body, "##[warning]"):
+		return log.SeverityWarn, "WARN"
+	default:
+		return log.SeverityInfo, "INFO"
+	}
+}
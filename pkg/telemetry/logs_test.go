@@ -0,0 +1,208 @@
+package telemetry
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/log"
+)
+
+// sampleJobLog is a job log as GitHub serves it: a byte order mark, timestamps
+// with seven fractional digits, and multiline output whose continuation lines
+// have no timestamp.
+const sampleJobLog = "\ufeff2024-05-01T10:00:00.1000000Z Current runner version: '2.317.0'\n" +
+	"2024-05-01T10:00:01.9000000Z ##[group]Run actions/checkout@v4\n" +
+	"2024-05-01T10:00:02.2500000Z Syncing repository: krzko/export-job-telemetry\n" +
+	"2024-05-01T10:00:05.0000000Z ##[group]Run go test ./...\n" +
+	"2024-05-01T10:00:05.3000000Z --- FAIL: TestExport (0.00s)\n" +
+	"    export_test.go:12: got 1, want 2\n" +
+	"FAIL\n" +
+	"2024-05-01T10:00:05.4000000Z ##[error]Process completed with exit code 1.\n" +
+	"2024-05-01T10:00:06.5000000Z Post job cleanup.\n" +
+	"2024-05-01T10:00:07.9990000Z Cleaning up orphan processes\n"
+
+func logTime(d time.Duration) time.Time {
+	return jobStart.Add(d)
+}
+
+func TestParseJobLog(t *testing.T) {
+	want := []LogLine{
+		{Timestamp: logTime(100 * time.Millisecond), Body: "Current runner version: '2.317.0'"},
+		{Timestamp: logTime(1900 * time.Millisecond), Body: "##[group]Run actions/checkout@v4"},
+		{Timestamp: logTime(2250 * time.Millisecond), Body: "Syncing repository: krzko/export-job-telemetry"},
+		{Timestamp: logTime(5 * time.Second), Body: "##[group]Run go test ./..."},
+		{Timestamp: logTime(5300 * time.Millisecond), Body: "--- FAIL: TestExport (0.00s)\n    export_test.go:12: got 1, want 2\nFAIL"},
+		{Timestamp: logTime(5400 * time.Millisecond), Body: "##[error]Process completed with exit code 1."},
+		{Timestamp: logTime(6500 * time.Millisecond), Body: "Post job cleanup."},
+		{Timestamp: logTime(7999 * time.Millisecond), Body: "Cleaning up orphan processes"},
+	}
+
+	got, err := ParseJobLog(strings.NewReader(sampleJobLog))
+	if err != nil {
+		t.Fatalf("ParseJobLog() error: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParseJobLog() returned %d lines, want %d: %q", len(got), len(want), got)
+	}
+	for i := range want {
+		if !got[i].Timestamp.Equal(want[i].Timestamp) || got[i].Body != want[i].Body {
+			t.Errorf("line %d = %s %q, want %s %q", i, got[i].Timestamp.Format(time.RFC3339Nano), got[i].Body, want[i].Timestamp.Format(time.RFC3339Nano), want[i].Body)
+		}
+	}
+}
+
+func TestParseJobLogEdgeCases(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want []string
+	}{
+		{name: "empty", log: "", want: nil},
+		{name: "leading lines without timestamp dropped", log: "orphan\n2024-05-01T10:00:00Z first\n", want: []string{"first"}},
+		{name: "BOM on a line without timestamp", log: "2024-05-01T10:00:00Z first\n\ufeffmore\n", want: []string{"first\nmore"}},
+		{name: "timestamp with offset", log: "2024-05-01T12:00:00+02:00 first\n", want: []string{"first"}},
+		{name: "invalid timestamp continues", log: "2024-05-01T10:00:00Z first\n2024-05-01 10:00:01 second\n", want: []string{"first\n2024-05-01 10:00:01 second"}},
+		{name: "timestamp without body continues", log: "2024-05-01T10:00:00Z first\n2024-05-01T10:00:01Z\n", want: []string{"first\n2024-05-01T10:00:01Z"}},
+		{name: "empty body", log: "2024-05-01T10:00:00Z \n", want: []string{""}},
+		{name: "CRLF", log: "2024-05-01T10:00:00Z first\r\n2024-05-01T10:00:01Z second\r\n", want: []string{"first", "second"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := ParseJobLog(strings.NewReader(tt.log))
+			if err != nil {
+				t.Fatalf("ParseJobLog() error: %v", err)
+			}
+			var got []string
+			for _, line := range lines {
+				got = append(got, line.Body)
+				if !line.Timestamp.Equal(jobStart) && !line.Timestamp.Equal(jobStart.Add(time.Second)) {
+					t.Errorf("line %q timestamp = %s, want %s", line.Body, line.Timestamp, jobStart)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseJobLog() bodies = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepLogLines(t *testing.T) {
+	lines, err := ParseJobLog(strings.NewReader(sampleJobLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(d time.Duration) *time.Time {
+		ts := jobStart.Add(d)
+		return &ts
+	}
+
+	tests := []struct {
+		name  string
+		steps []Step
+		want  [][]string
+	}{
+		{
+			name: "consecutive steps",
+			steps: []Step{
+				{Name: "Set up job", StartedAt: at(0), CompletedAt: at(time.Second)},
+				{Name: "Checkout", StartedAt: at(time.Second), CompletedAt: at(5 * time.Second)},
+				// Completes in the second it started, as failing steps often do.
+				{Name: "Test", StartedAt: at(5 * time.Second), CompletedAt: at(5 * time.Second)},
+				{Name: "Complete job", StartedAt: at(6 * time.Second), CompletedAt: at(7 * time.Second)},
+			},
+			want: [][]string{
+				{"Current runner version: '2.317.0'"},
+				{"##[group]Run actions/checkout@v4", "Syncing repository: krzko/export-job-telemetry"},
+				{"##[group]Run go test ./...", "--- FAIL: TestExport (0.00s)\n    export_test.go:12: got 1, want 2\nFAIL", "##[error]Process completed with exit code 1."},
+				{"Post job cleanup.", "Cleaning up orphan processes"},
+			},
+		},
+		{
+			name: "steps starting in the same second",
+			steps: []Step{
+				{Name: "Test", StartedAt: at(5 * time.Second), CompletedAt: at(5 * time.Second)},
+				{Name: "Report", StartedAt: at(5 * time.Second), CompletedAt: at(5 * time.Second)},
+			},
+			want: [][]string{
+				{"##[group]Run go test ./...", "--- FAIL: TestExport (0.00s)\n    export_test.go:12: got 1, want 2\nFAIL", "##[error]Process completed with exit code 1."},
+				nil,
+			},
+		},
+		{
+			name: "last step ends with the second it completed in",
+			steps: []Step{
+				{Name: "Checkout", StartedAt: at(time.Second), CompletedAt: at(2 * time.Second)},
+			},
+			want: [][]string{
+				{"##[group]Run actions/checkout@v4", "Syncing repository: krzko/export-job-telemetry"},
+			},
+		},
+		{
+			name: "step in progress",
+			steps: []Step{
+				{Name: "Complete job", StartedAt: at(6 * time.Second)},
+			},
+			want: [][]string{
+				{"Post job cleanup.", "Cleaning up orphan processes"},
+			},
+		},
+		{
+			name: "steps that did not start",
+			steps: []Step{
+				{Name: "Checkout", StartedAt: at(time.Second), CompletedAt: at(2 * time.Second)},
+				{Name: "Skipped"},
+				{Name: "Test", StartedAt: at(5 * time.Second), CompletedAt: at(5 * time.Second)},
+			},
+			want: [][]string{
+				{"##[group]Run actions/checkout@v4", "Syncing repository: krzko/export-job-telemetry"},
+				nil,
+				{"##[group]Run go test ./...", "--- FAIL: TestExport (0.00s)\n    export_test.go:12: got 1, want 2\nFAIL", "##[error]Process completed with exit code 1."},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stepLines := StepLogLines(lines, tt.steps)
+			if len(stepLines) != len(tt.steps) {
+				t.Fatalf("StepLogLines() returned %d steps, want %d", len(stepLines), len(tt.steps))
+			}
+
+			seen := make(map[string]string)
+			for i, step := range tt.steps {
+				var got []string
+				for _, line := range stepLines[i] {
+					got = append(got, line.Body)
+					if other, ok := seen[line.Body]; ok {
+						t.Errorf("line %q went to steps %q and %q", line.Body, other, step.Name)
+					}
+					seen[line.Body] = step.Name
+				}
+				if !reflect.DeepEqual(got, tt.want[i]) {
+					t.Errorf("lines of step %q = %q, want %q", step.Name, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLogLineSeverity(t *testing.T) {
+	tests := []struct {
+		body         string
+		want         log.Severity
+		wantSeverity string
+	}{
+		{body: "##[error]Process completed with exit code 1.", want: log.SeverityError, wantSeverity: "ERROR"},
+		{body: "##[warning]Node.js 16 actions are deprecated.", want: log.SeverityWarn, wantSeverity: "WARN"},
+		{body: "##[group]Run go test ./...", want: log.SeverityInfo, wantSeverity: "INFO"},
+		{body: "error: not a workflow command", want: log.SeverityInfo, wantSeverity: "INFO"},
+		{body: "", want: log.SeverityInfo, wantSeverity: "INFO"},
+	}
+	for _, tt := range tests {
+		got, gotText := logLineSeverity(tt.body)
+		if got != tt.want || gotText != tt.wantSeverity {
+			t.Errorf("logLineSeverity(%q) = %v, %q, want %v, %q", tt.body, got, gotText, tt.want, tt.wantSeverity)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Matrix holds the matrix values of a job leg, e.g. os=ubuntu-latest.
+type Matrix map[string]string
+
+// ParseMatrix parses the matrix values of a job, either as the JSON object
+// produced by toJSON(matrix), or as comma-separated key=value pairs. Nested
+// JSON values are kept as compact JSON.
+func ParseMatrix(input string) (Matrix, error) {
+	input = strings.TrimSpace(input)
+	if input == "" || input == "null" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(input, "{") {
+		return Matrix(ParseKeyValuePairs(input)), nil
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(input), &values); err != nil {
+		return nil, fmt.Errorf("invalid matrix: %w", err)
+	}
+
+	matrix := make(Matrix, len(values))
+	for k, raw := range values {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			matrix[k] = s
+			continue
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, raw); err != nil {
+			return nil, fmt.Errorf("invalid matrix value %q: %w", k, err)
+		}
+		matrix[k] = compact.String()
+	}
+	return matrix, nil
+}
+
+func (m Matrix) keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String formats the matrix as sorted key=value pairs, e.g.
+// go=1.22, os=ubuntu-latest.
+func (m Matrix) String() string {
+	pairs := make([]string, 0, len(m))
+	for _, k := range m.keys() {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// Attributes returns the matrix values as ci.github.workflow.job.matrix.*
+// attributes.
+func (m Matrix) Attributes() []attribute.KeyValue {
+	attributes := make([]attribute.KeyValue, 0, len(m))
+	for _, k := range m.keys() {
+		attributes = append(attributes, attribute.String("ci.github.workflow.job.matrix."+k, m[k]))
+	}
+	return attributes
+}
@@ -0,0 +1,309 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"google.golang.org/grpc/credentials"
+)
+
+const defaultOTLPMetricsPath = "/v1/metrics"
+
+// jobDurationBuckets are the bucket boundaries, in seconds, of the job duration
+// and queue latency histograms, spanning jobs of seconds to an hour.
+var jobDurationBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}
+
+// JobMetrics holds the measurements of a CI job. Measurements that are not
+// known are nil and not recorded.
+type JobMetrics struct {
+	Duration          *time.Duration
+	QueueLatency      *time.Duration
+	CacheHit          *bool
+	CacheSizeBytes    *int64
+	ArtifactCount     *int64
+	ArtifactSizeBytes *int64
+	ResourceUsage     *ResourceUsage
+	Conclusion        string
+	JobName           string
+}
+
+// NewMetricExporter creates a metric exporter for the configured type and
+// protocol.
+func NewMetricExporter(ctx context.Context, cfg ExporterConfig) (sdkmetric.Exporter, error) {
+	if cfg.Type == ExporterTypeFile {
+		return nil, errors.New("the file exporter only supports traces")
+	}
+	if cfg.Type == ExporterTypeConsole {
+		return stdoutmetric.New(stdoutmetric.WithWriter(cfg.consoleWriter()), stdoutmetric.WithPrettyPrint(), stdoutmetric.WithTemporalitySelector(deltaTemporality))
+	}
+
+	if cfg.Protocol == ProtocolHTTPProtobuf {
+		endpointURL, err := HTTPSignalURL(cfg.Endpoint, defaultOTLPMetricsPath)
+		if err != nil {
+			return nil, err
+		}
+
+		proxy, stopProxy, err := cfg.httpProxy()
+		if err != nil {
+			return nil, err
+		}
+		clientOptions := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithHeaders(cfg.Headers),
+			otlpmetrichttp.WithProxy(proxy),
+			otlpmetrichttp.WithTemporalitySelector(deltaTemporality),
+		}
+		if endpointURL.Scheme == "" {
+			clientOptions = append(clientOptions,
+				otlpmetrichttp.WithEndpoint(endpointURL.Host),
+				otlpmetrichttp.WithURLPath(endpointURL.Path),
+			)
+		} else {
+			clientOptions = append(clientOptions, otlpmetrichttp.WithEndpointURL(endpointURL.String()))
+		}
+		if cfg.Insecure {
+			clientOptions = append(clientOptions, otlpmetrichttp.WithInsecure())
+		} else if tlsConfig, err := cfg.TLSConfig(); err != nil {
+			stopProxy()
+			return nil, err
+		} else if tlsConfig != nil {
+			clientOptions = append(clientOptions, otlpmetrichttp.WithTLSClientConfig(tlsConfig))
+		}
+		if cfg.Timeout > 0 {
+			clientOptions = append(clientOptions, otlpmetrichttp.WithTimeout(cfg.Timeout))
+		}
+		if cfg.Compression == CompressionGzip {
+			clientOptions = append(clientOptions, otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression))
+		}
+		if r := cfg.Retry; r != nil {
+			r := r.withDefaults()
+			clientOptions = append(clientOptions, otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig{
+				Enabled:         r.Enabled,
+				InitialInterval: r.InitialInterval,
+				MaxInterval:     r.MaxInterval,
+				MaxElapsedTime:  r.MaxElapsedTime,
+			}))
+		}
+		exp, err := otlpmetrichttp.New(ctx, clientOptions...)
+		if err != nil {
+			stopProxy()
+			return nil, err
+		}
+		return &stopProxyMetricExporter{Exporter: exp, stopProxy: stopProxy}, nil
+	}
+
+	dialOption, err := cfg.grpcDialOption()
+	if err != nil {
+		return nil, err
+	}
+	clientOptions := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(cfg.grpcTarget()),
+		otlpmetricgrpc.WithHeaders(cfg.Headers),
+		otlpmetricgrpc.WithDialOption(dialOption),
+		otlpmetricgrpc.WithTemporalitySelector(deltaTemporality),
+	}
+	if cfg.Insecure {
+		clientOptions = append(clientOptions, otlpmetricgrpc.WithInsecure())
+	} else if tlsConfig, err := cfg.TLSConfig(); err != nil {
+		return nil, err
+	} else if tlsConfig != nil {
+		clientOptions = append(clientOptions, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	if cfg.Timeout > 0 {
+		clientOptions = append(clientOptions, otlpmetricgrpc.WithTimeout(cfg.Timeout))
+	}
+	if cfg.Compression == CompressionGzip {
+		clientOptions = append(clientOptions, otlpmetricgrpc.WithCompressor(CompressionGzip))
+	}
+	if r := cfg.Retry; r != nil {
+		r := r.withDefaults()
+		clientOptions = append(clientOptions, otlpmetricgrpc.WithRetry(otlpmetricgrpc.RetryConfig{
+			Enabled:         r.Enabled,
+			InitialInterval: r.InitialInterval,
+			MaxInterval:     r.MaxInterval,
+			MaxElapsedTime:  r.MaxElapsedTime,
+		}))
+	}
+	return otlpmetricgrpc.New(ctx, clientOptions...)
+}
+
+// deltaTemporality exports counters and histograms as deltas. Every job records
+// its measurements in a new process, so cumulative series would restart at
+// each run rather than add up across runs.
+func deltaTemporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	switch kind {
+	case sdkmetric.InstrumentKindCounter, sdkmetric.InstrumentKindHistogram, sdkmetric.InstrumentKindObservableCounter:
+		return metricdata.DeltaTemporality
+	default:
+		return metricdata.CumulativeTemporality
+	}
+}
+
+// RecordJobMetrics records the job measurements and exports them once through a
+// short-lived meter provider.
+func RecordJobMetrics(ctx context.Context, cfg ExporterConfig, res *resource.Resource, m JobMetrics) error {
+	exp, err := NewMetricExporter(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize metric exporter: %w", err)
+	}
+
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp)),
+		sdkmetric.WithResource(res),
+	)
+
+	if err := recordJobMetrics(ctx, meterProvider.Meter(InstrumentationName), m); err != nil {
+		_ = meterProvider.Shutdown(ctx)
+		return err
+	}
+
+	if err := meterProvider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down meter provider: %w", err)
+	}
+	return nil
+}
+
+func recordJobMetrics(ctx context.Context, meter metric.Meter, m JobMetrics) error {
+	duration, err := meter.Float64Histogram("ci.github.workflow.job.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of the GitHub Actions job."),
+		metric.WithExplicitBucketBoundaries(jobDurationBuckets...))
+	if err != nil {
+		return fmt.Errorf("failed to create duration histogram: %w", err)
+	}
+
+	queueLatency, err := meter.Float64Histogram("ci.github.workflow.job.queue_latency",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time the GitHub Actions job waited between creation and start."),
+		metric.WithExplicitBucketBoundaries(jobDurationBuckets...))
+	if err != nil {
+		return fmt.Errorf("failed to create queue latency histogram: %w", err)
+	}
+
+	runs, err := meter.Int64Counter("ci.github.workflow.job.runs",
+		metric.WithDescription("Number of GitHub Actions job runs by conclusion."))
+	if err != nil {
+		return fmt.Errorf("failed to create runs counter: %w", err)
+	}
+
+	cacheLookups, err := meter.Int64Counter("ci.github.workflow.job.cache.lookups",
+		metric.WithDescription("Number of GitHub Actions job cache lookups by hit."))
+	if err != nil {
+		return fmt.Errorf("failed to create cache lookups counter: %w", err)
+	}
+
+	cacheSize, err := meter.Int64Histogram("ci.github.workflow.job.cache.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of the cache used by the GitHub Actions job."))
+	if err != nil {
+		return fmt.Errorf("failed to create cache size histogram: %w", err)
+	}
+
+	artifacts, err := meter.Int64Counter("ci.github.workflow.job.artifacts",
+		metric.WithDescription("Number of artifacts uploaded by GitHub Actions jobs."))
+	if err != nil {
+		return fmt.Errorf("failed to create artifacts counter: %w", err)
+	}
+
+	artifactSize, err := meter.Int64Histogram("ci.github.workflow.job.artifact.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of the artifacts uploaded by the GitHub Actions job."))
+	if err != nil {
+		return fmt.Errorf("failed to create artifact size histogram: %w", err)
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("ci.github.workflow.job.conclusion", m.Conclusion),
+	}
+	if m.JobName != "" {
+		attrs = append(attrs, attribute.String("ci.github.workflow.job.name", m.JobName))
+	}
+	opt := metric.WithAttributes(attrs...)
+
+	if m.Duration != nil {
+		duration.Record(ctx, m.Duration.Seconds(), opt)
+	}
+	if m.QueueLatency != nil {
+		queueLatency.Record(ctx, m.QueueLatency.Seconds(), opt)
+	}
+	runs.Add(ctx, 1, opt)
+	if m.CacheHit != nil {
+		cacheLookups.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.Bool("ci.github.workflow.job.cache.hit", *m.CacheHit))...))
+	}
+	if m.CacheSizeBytes != nil {
+		cacheSize.Record(ctx, *m.CacheSizeBytes, opt)
+	}
+	if m.ArtifactCount != nil {
+		artifacts.Add(ctx, *m.ArtifactCount, opt)
+	}
+	if m.ArtifactSizeBytes != nil {
+		artifactSize.Record(ctx, *m.ArtifactSizeBytes, opt)
+	}
+	if m.ResourceUsage != nil {
+		return recordResourceUsage(ctx, meter, *m.ResourceUsage, opt)
+	}
+	return nil
+}
+
+func recordResourceUsage(ctx context.Context, meter metric.Meter, u ResourceUsage, opt metric.RecordOption) error {
+	load, err := meter.Float64Gauge("ci.github.workflow.job.runner.cpu.load",
+		metric.WithUnit("1"),
+		metric.WithDescription("Highest of the 1, 5 and 15 minute load averages of the runner at the end of the job, per CPU."))
+	if err != nil {
+		return fmt.Errorf("failed to create CPU load gauge: %w", err)
+	}
+
+	utilization, err := meter.Float64Gauge("ci.github.workflow.job.runner.cpu.utilization",
+		metric.WithUnit("1"),
+		metric.WithDescription("Average share of the runner's CPUs used by its cgroup during the job."))
+	if err != nil {
+		return fmt.Errorf("failed to create CPU utilization gauge: %w", err)
+	}
+
+	memory, err := meter.Int64Gauge("ci.github.workflow.job.runner.memory.usage",
+		metric.WithUnit("By"),
+		metric.WithDescription("Memory use of the runner at the end of the job."))
+	if err != nil {
+		return fmt.Errorf("failed to create memory usage gauge: %w", err)
+	}
+
+	memoryPeak, err := meter.Int64Gauge("ci.github.workflow.job.runner.memory.peak",
+		metric.WithUnit("By"),
+		metric.WithDescription("Peak memory use of the runner's cgroup."))
+	if err != nil {
+		return fmt.Errorf("failed to create memory peak gauge: %w", err)
+	}
+
+	disk, err := meter.Int64Gauge("ci.github.workflow.job.runner.disk.usage",
+		metric.WithUnit("By"),
+		metric.WithDescription("Disk use of the runner's workspace filesystem at the end of the job."))
+	if err != nil {
+		return fmt.Errorf("failed to create disk usage gauge: %w", err)
+	}
+
+	if u.CPUCount > 0 {
+		load.Record(ctx, max(u.Load1, u.Load5, u.Load15)/float64(u.CPUCount), opt)
+	}
+	if u.CPUUtilization > 0 {
+		utilization.Record(ctx, u.CPUUtilization, opt)
+	}
+	if u.MemoryCurrentBytes > 0 {
+		memory.Record(ctx, u.MemoryCurrentBytes, opt)
+	}
+	if u.MemoryPeakBytes > 0 {
+		memoryPeak.Record(ctx, u.MemoryPeakBytes, opt)
+	}
+	if u.DiskUsedBytes > 0 {
+		disk.Record(ctx, u.DiskUsedBytes, opt)
+	}
+	return nil
+}
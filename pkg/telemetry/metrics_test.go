@@ -0,0 +1,62 @@
+package telemetry
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRecordJobMetricsDurations(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer provider.Shutdown(context.Background())
+
+	duration, queueLatency := 90*time.Second, 1500*time.Millisecond
+	err := recordJobMetrics(context.Background(), provider.Meter(InstrumentationName), JobMetrics{
+		Duration:     &duration,
+		QueueLatency: &queueLatency,
+		Conclusion:   "success",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{
+		"ci.github.workflow.job.duration":      90,
+		"ci.github.workflow.job.queue_latency": 1.5,
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			wantSum, ok := want[m.Name]
+			if !ok {
+				continue
+			}
+			delete(want, m.Name)
+			if m.Unit != "s" {
+				t.Errorf("%s unit = %q, want s", m.Name, m.Unit)
+			}
+			histogram, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok || len(histogram.DataPoints) != 1 {
+				t.Fatalf("%s = %T, want one float histogram data point", m.Name, m.Data)
+			}
+			point := histogram.DataPoints[0]
+			if point.Sum != wantSum {
+				t.Errorf("%s sum = %v, want %v", m.Name, point.Sum, wantSum)
+			}
+			if !reflect.DeepEqual(point.Bounds, jobDurationBuckets) {
+				t.Errorf("%s bounds = %v, want %v", m.Name, point.Bounds, jobDurationBuckets)
+			}
+		}
+	}
+	for name := range want {
+		t.Errorf("%s was not recorded", name)
+	}
+}
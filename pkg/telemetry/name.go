@@ -0,0 +1,73 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultJobSpanName is the name of the job span when none is configured.
+const DefaultJobSpanName = "Job telemetry"
+
+// ExpandSpanName replaces the {placeholder}s in template with values. An
+// unknown placeholder is an error, so typos do not end up in span names.
+func ExpandSpanName(template string, values map[string]string) (string, error) {
+	name, err := expandTemplate("span name", template, values)
+	return strings.TrimSpace(name), err
+}
+
+// TraceURL expands the {trace_id} and {span_id} placeholders of template, the
+// URL of a trace in a tracing backend, for spanContext.
+func TraceURL(template string, spanContext trace.SpanContext) (string, error) {
+	return expandTemplate("trace URL", template, map[string]string{
+		"trace_id": spanContext.TraceID().String(),
+		"span_id":  spanContext.SpanID().String(),
+	})
+}
+
+func expandTemplate(kind, template string, values map[string]string) (string, error) {
+	var expanded strings.Builder
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			expanded.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("invalid %s %q: unclosed placeholder", kind, template)
+		}
+		end += start
+
+		placeholder := rest[start+1 : end]
+		value, ok := values[placeholder]
+		if !ok {
+			return "", fmt.Errorf("invalid %s %q: unknown placeholder {%s}", kind, template, placeholder)
+		}
+		expanded.WriteString(rest[:start])
+		expanded.WriteString(value)
+		rest = rest[end+1:]
+	}
+	return expanded.String(), nil
+}
+
+// ParseSpanKind parses a span kind such as internal or server. An empty value
+// defaults to internal.
+func ParseSpanKind(value string) (trace.SpanKind, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "internal":
+		return trace.SpanKindInternal, nil
+	case "server":
+		return trace.SpanKindServer, nil
+	case "client":
+		return trace.SpanKindClient, nil
+	case "producer":
+		return trace.SpanKindProducer, nil
+	case "consumer":
+		return trace.SpanKindConsumer, nil
+	default:
+		return trace.SpanKindUnspecified, fmt.Errorf("invalid span kind: %q, expected internal, server, client, producer or consumer", value)
+	}
+}
@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// OTLP JSON differs from the canonical protobuf JSON mapping in encoding trace
+// and span ids as hex rather than base64, and enums as numbers.
+var otlpJSONIDFields = map[string]bool{"traceId": true, "spanId": true, "parentSpanId": true}
+
+// MarshalTracesJSON encodes spans as an OTLP JSON ExportTraceServiceRequest, as
+// accepted by OTLP/HTTP receivers and the collector's otlpjson receiver.
+func MarshalTracesJSON(resourceSpans []*tracepb.ResourceSpans) ([]byte, error) {
+	data, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: resourceSpans})
+	if err != nil {
+		return nil, err
+	}
+	return convertIDs(data, func(id string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(id)
+		return hex.EncodeToString(b), err
+	})
+}
+
+// UnmarshalTracesJSON decodes an OTLP JSON ExportTraceServiceRequest.
+func UnmarshalTracesJSON(data []byte) ([]*tracepb.ResourceSpans, error) {
+	data, err := convertIDs(data, func(id string) (string, error) {
+		b, err := hex.DecodeString(id)
+		return base64.StdEncoding.EncodeToString(b), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var request coltracepb.ExportTraceServiceRequest
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, &request); err != nil {
+		return nil, err
+	}
+	return request.ResourceSpans, nil
+}
+
+// convertIDs re-encodes the id fields of a JSON document with convert.
+func convertIDs(data []byte, convert func(string) (string, error)) ([]byte, error) {
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	var walk func(v any) error
+	walk = func(v any) error {
+		switch v := v.(type) {
+		case map[string]any:
+			for key, value := range v {
+				if id, ok := value.(string); ok && otlpJSONIDFields[key] {
+					converted, err := convert(id)
+					if err != nil {
+						return fmt.Errorf("invalid %s %q: %w", key, id, err)
+					}
+					v[key] = converted
+					continue
+				}
+				if err := walk(value); err != nil {
+					return err
+				}
+			}
+		case []any:
+			for _, value := range v {
+				if err := walk(value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
@@ -0,0 +1,134 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// testResourceSpans returns a failed job span with a step child span.
+func testResourceSpans(t *testing.T) []*tracepb.ResourceSpans {
+	t.Helper()
+	traceID := mustDecodeHex(t, "4bf92f3577b34da6a3ce929d0e0e4736")
+	jobSpanID := mustDecodeHex(t, "00f067aa0ba902b7")
+	return []*tracepb.ResourceSpans{{
+		Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+			{Key: "service.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "ci"}}},
+		}},
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Scope: &commonpb.InstrumentationScope{Name: InstrumentationName},
+			Spans: []*tracepb.Span{
+				{
+					TraceId:           traceID,
+					SpanId:            mustDecodeHex(t, "b7ad6b7169203331"),
+					ParentSpanId:      jobSpanID,
+					Name:              "Test",
+					Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+					StartTimeUnixNano: 1714557610000000000,
+					EndTimeUnixNano:   1714557650000000000,
+					Status:            &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: "Step failed"},
+				},
+				{
+					TraceId:           traceID,
+					SpanId:            jobSpanID,
+					Name:              "build",
+					Kind:              tracepb.Span_SPAN_KIND_SERVER,
+					StartTimeUnixNano: 1714557600000000000,
+					EndTimeUnixNano:   1714557660000000000,
+					Attributes: []*commonpb.KeyValue{
+						{Key: "ci.github.workflow.job.conclusion", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "failure"}}},
+					},
+				},
+			},
+		}},
+	}}
+}
+
+func TestTracesJSONRoundTrip(t *testing.T) {
+	want := testResourceSpans(t)
+
+	data, err := MarshalTracesJSON(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{
+		`"traceId":"4bf92f3577b34da6a3ce929d0e0e4736"`,
+		`"spanId":"00f067aa0ba902b7"`,
+		`"parentSpanId":"00f067aa0ba902b7"`,
+		`"kind":2`,
+		`"code":2`,
+	} {
+		if !bytes.Contains(data, []byte(field)) {
+			t.Errorf("MarshalTracesJSON() = %s, want it to contain %s", data, field)
+		}
+	}
+
+	got, err := UnmarshalTracesJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) || !proto.Equal(got[0], want[0]) {
+		t.Errorf("UnmarshalTracesJSON() = %v, want %v", got, want)
+	}
+}
+
+func TestUnmarshalTracesJSONErrors(t *testing.T) {
+	for _, data := range []string{
+		`not json`,
+		`{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"not-hex"}]}]}]}`,
+		`{"resourceSpans":"spans"}`,
+	} {
+		if _, err := UnmarshalTracesJSON([]byte(data)); err == nil {
+			t.Errorf("UnmarshalTracesJSON(%s) succeeded, want error", data)
+		}
+	}
+}
+
+func TestFileSpanExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	exp, err := NewSpanExporter(context.Background(), ExporterConfig{Type: ExporterTypeFile, FilePath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	_, span := provider.Tracer(InstrumentationName).Start(context.Background(), "build")
+	span.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resourceSpans, err := UnmarshalTracesJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spans := resourceSpans[0].GetScopeSpans()[0].GetSpans()
+	if len(resourceSpans) != 1 || len(spans) != 1 {
+		t.Fatalf("wrote %v, want a single span", resourceSpans)
+	}
+	if spans[0].GetName() != "build" || !bytes.Equal(spans[0].GetSpanId(), mustDecodeHex(t, span.SpanContext().SpanID().String())) {
+		t.Errorf("wrote span %q %x, want build %s", spans[0].GetName(), spans[0].GetSpanId(), span.SpanContext().SpanID())
+	}
+}
@@ -0,0 +1,120 @@
+package telemetry
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Names of the vendor presets.
+const (
+	PresetDatadog      = "datadog"
+	PresetGrafanaCloud = "grafana-cloud"
+	PresetHoneycomb    = "honeycomb"
+	PresetJaeger       = "jaeger"
+)
+
+// Preset holds the endpoint, protocol and authentication conventions of a
+// tracing backend.
+type Preset struct {
+	Name string
+	// Endpoint is empty when the endpoint depends on the account, e.g. its
+	// region, and must be configured.
+	Endpoint string
+	Protocol string
+	// Insecure connects without TLS, as the backend's default receiver does.
+	Insecure bool
+	// Headers returns the headers that authenticate with apiKey, or nil when
+	// the backend does not take an API key.
+	Headers func(apiKey string) (map[string]string, error)
+}
+
+var presets = map[string]Preset{
+	PresetDatadog: {
+		Name:     PresetDatadog,
+		Endpoint: "https://otlp.datadoghq.com",
+		Protocol: ProtocolHTTPProtobuf,
+		Headers: func(apiKey string) (map[string]string, error) {
+			return map[string]string{"dd-api-key": apiKey}, nil
+		},
+	},
+	PresetGrafanaCloud: {
+		Name:     PresetGrafanaCloud,
+		Protocol: ProtocolHTTPProtobuf,
+		Headers: func(apiKey string) (map[string]string, error) {
+			if !strings.Contains(apiKey, ":") {
+				return nil, fmt.Errorf("expected the API key of %s as <instance id>:<token>", PresetGrafanaCloud)
+			}
+			return map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(apiKey))}, nil
+		},
+	},
+	PresetHoneycomb: {
+		Name:     PresetHoneycomb,
+		Endpoint: "api.honeycomb.io:443",
+		Protocol: ProtocolGRPC,
+		Headers: func(apiKey string) (map[string]string, error) {
+			return map[string]string{"x-honeycomb-team": apiKey}, nil
+		},
+	},
+	PresetJaeger: {
+		Name:     PresetJaeger,
+		Endpoint: "localhost:4317",
+		Protocol: ProtocolGRPC,
+		Insecure: true,
+	},
+}
+
+// LookupPreset returns the preset with the given name.
+func LookupPreset(name string) (Preset, error) {
+	preset, ok := presets[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset: %q, expected one of %s", name, strings.Join(PresetNames(), ", "))
+	}
+	return preset, nil
+}
+
+// Apply fills in the endpoint, protocol, insecure flag and authentication
+// headers of cfg that are not configured explicitly. insecureSet reports
+// whether cfg.Insecure was, as false cannot be told apart from unset.
+func (p Preset) Apply(cfg *ExporterConfig, apiKey string, insecureSet bool) error {
+	if cfg.Endpoint == "" {
+		if p.Endpoint == "" {
+			return fmt.Errorf("preset %s requires otel-exporter-otlp-endpoint", p.Name)
+		}
+		cfg.Endpoint = p.Endpoint
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = p.Protocol
+	}
+	if !insecureSet {
+		cfg.Insecure = p.Insecure
+	}
+
+	if p.Headers == nil {
+		return nil
+	}
+	if apiKey == "" {
+		// The headers may have been configured explicitly instead.
+		if len(cfg.Headers) > 0 {
+			return nil
+		}
+		return fmt.Errorf("preset %s requires preset-api-key", p.Name)
+	}
+	headers, err := p.Headers(apiKey)
+	if err != nil {
+		return err
+	}
+	cfg.Headers = MergeKeyValuePairs(headers, cfg.Headers)
+	return nil
+}
+
+// PresetNames returns the names of the presets, sorted.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,198 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config configures an Exporter.
+type Config struct {
+	// Exporters are the endpoints that every span is exported to. Each gets its
+	// own span processor, so a failing endpoint does not hold up the others.
+	Exporters []ExporterConfig
+	Resource  *resource.Resource
+
+	// Synchronous exports each span as soon as it ends instead of from the
+	// background batcher, and tracks delivery. Use it on ephemeral runners that
+	// may be terminated as soon as the process exits.
+	Synchronous bool
+
+	// FlushTimeout, if set, bounds each synchronous export, including its
+	// retries, so that ending a span cannot hold up the job for long.
+	FlushTimeout time.Duration
+
+	// Sampler decides which traces are exported. When nil, spans are sampled
+	// like their parent, and root spans always are.
+	Sampler sdktrace.Sampler
+
+	// OnExport, if set, is called with the outcome of every span export.
+	OnExport func(endpoint string, spans int, err error)
+}
+
+// Exporter owns the tracer provider that CI spans are recorded with and
+// exported from.
+type Exporter struct {
+	provider   *sdktrace.TracerProvider
+	deliveries []*deliveryExporter
+}
+
+// Delivery summarises the exports of a synchronous Exporter to one endpoint.
+type Delivery struct {
+	Endpoint string
+	Spans    int
+	Err      error
+}
+
+// NewExporter creates an Exporter that sends spans to the configured
+// endpoints.
+func NewExporter(ctx context.Context, cfg Config) (*Exporter, error) {
+	if len(cfg.Exporters) == 0 {
+		return nil, errors.New("no exporter configured")
+	}
+
+	exporters := make([]sdktrace.SpanExporter, 0, len(cfg.Exporters))
+	for _, exporterConfig := range cfg.Exporters {
+		if cfg.Synchronous && cfg.FlushTimeout > 0 {
+			exporterConfig.Retry = boundRetry(exporterConfig.Retry, cfg.FlushTimeout)
+		}
+		exp, err := NewSpanExporter(ctx, exporterConfig)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", exporterConfig.Name(), err)
+		}
+		exporters = append(exporters, &endpointExporter{SpanExporter: exp, endpoint: exporterConfig.Name(), onExport: cfg.OnExport})
+	}
+	return NewExporterWithSpanExporters(exporters, cfg), nil
+}
+
+// NewExporterWithSpanExporters creates an Exporter around existing span
+// exporters, ignoring cfg.Exporters.
+func NewExporterWithSpanExporters(exporters []sdktrace.SpanExporter, cfg Config) *Exporter {
+	e := &Exporter{}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithIDGenerator(IDGenerator{}),
+	}
+	for _, exp := range exporters {
+		if cfg.Synchronous {
+			delivery := &deliveryExporter{SpanExporter: exp, timeout: cfg.FlushTimeout}
+			e.deliveries = append(e.deliveries, delivery)
+			options = append(options, sdktrace.WithSyncer(delivery))
+		} else {
+			options = append(options, sdktrace.WithBatcher(exp))
+		}
+	}
+	if cfg.Resource != nil {
+		options = append(options, sdktrace.WithResource(cfg.Resource))
+	}
+	if cfg.Sampler != nil {
+		options = append(options, sdktrace.WithSampler(cfg.Sampler))
+	}
+	e.provider = sdktrace.NewTracerProvider(options...)
+
+	return e
+}
+
+// TracerProvider returns the underlying tracer provider.
+func (e *Exporter) TracerProvider() *sdktrace.TracerProvider {
+	return e.provider
+}
+
+// Tracer returns the tracer CI spans are recorded with.
+func (e *Exporter) Tracer() trace.Tracer {
+	return e.provider.Tracer(InstrumentationName)
+}
+
+// Shutdown flushes outstanding spans and shuts the exporter down.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	return e.provider.Shutdown(ctx)
+}
+
+// Deliveries reports the exports so far, per endpoint. It returns nothing
+// unless the Exporter is synchronous.
+func (e *Exporter) Deliveries() []Delivery {
+	deliveries := make([]Delivery, 0, len(e.deliveries))
+	for _, delivery := range e.deliveries {
+		delivery.mu.Lock()
+		deliveries = append(deliveries, Delivery{
+			Endpoint: endpointName(delivery.SpanExporter),
+			Spans:    delivery.delivered,
+			Err:      delivery.err,
+		})
+		delivery.mu.Unlock()
+	}
+	return deliveries
+}
+
+// endpointExporter labels the errors of a span exporter with its endpoint, so
+// failures are reported per endpoint.
+type endpointExporter struct {
+	sdktrace.SpanExporter
+	endpoint string
+	onExport func(endpoint string, spans int, err error)
+}
+
+func (e *endpointExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	if e.onExport != nil {
+		e.onExport(e.endpoint, len(spans), err)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", e.endpoint, err)
+	}
+	return nil
+}
+
+func endpointName(exp sdktrace.SpanExporter) string {
+	if e, ok := exp.(*endpointExporter); ok {
+		return e.endpoint
+	}
+	return ""
+}
+
+// deliveryExporter records the outcome of every export so that delivery can be
+// confirmed before the process exits.
+type deliveryExporter struct {
+	sdktrace.SpanExporter
+	timeout time.Duration
+
+	mu        sync.Mutex
+	delivered int
+	err       error
+}
+
+func (e *deliveryExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err != nil {
+		e.err = errors.Join(e.err, err)
+	} else {
+		e.delivered += len(spans)
+	}
+	return err
+}
+
+// boundRetry returns a copy of cfg, or the default retry policy when nil, that
+// gives up once timeout has passed.
+func boundRetry(cfg *RetryConfig, timeout time.Duration) *RetryConfig {
+	bounded := RetryConfig{Enabled: true}
+	if cfg != nil {
+		bounded = *cfg
+	}
+	bounded = bounded.withDefaults()
+	bounded.MaxElapsedTime = min(bounded.MaxElapsedTime, timeout)
+	return &bounded
+}
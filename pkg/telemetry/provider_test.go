@@ -0,0 +1,73 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSynchronousExportBoundedByFlushTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter, err := NewExporter(context.Background(), Config{
+		Exporters: []ExporterConfig{{
+			Endpoint: server.URL,
+			Protocol: ProtocolHTTPProtobuf,
+			Retry:    &RetryConfig{Enabled: true, InitialInterval: 50 * time.Millisecond, MaxInterval: 50 * time.Millisecond, MaxElapsedTime: time.Hour},
+		}},
+		Synchronous:  true,
+		FlushTimeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Shutdown(context.Background())
+
+	start := time.Now()
+	_, span := exporter.Tracer().Start(context.Background(), "build")
+	span.End()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("span.End() took %s, want it bounded by the flush timeout", elapsed)
+	}
+
+	deliveries := exporter.Deliveries()
+	if len(deliveries) != 1 || deliveries[0].Err == nil || deliveries[0].Spans != 0 {
+		t.Errorf("Deliveries() = %+v, want one failed delivery", deliveries)
+	}
+}
+
+func TestBoundRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *RetryConfig
+		want RetryConfig
+	}{
+		{
+			name: "default policy",
+			want: RetryConfig{Enabled: true, InitialInterval: DefaultRetryInitialInterval, MaxInterval: DefaultRetryMaxInterval, MaxElapsedTime: 10 * time.Second},
+		},
+		{
+			name: "shorter max elapsed time kept",
+			cfg:  &RetryConfig{Enabled: true, MaxElapsedTime: time.Second},
+			want: RetryConfig{Enabled: true, InitialInterval: DefaultRetryInitialInterval, MaxInterval: DefaultRetryMaxInterval, MaxElapsedTime: time.Second},
+		},
+		{
+			name: "disabled",
+			cfg:  &RetryConfig{},
+			want: RetryConfig{InitialInterval: DefaultRetryInitialInterval, MaxInterval: DefaultRetryMaxInterval, MaxElapsedTime: 10 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := boundRetry(tt.cfg, 10*time.Second); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("boundRetry() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"google.golang.org/grpc"
+)
+
+// proxy returns the proxy of the exporter's requests: ProxyURL when set,
+// otherwise the proxy configured through the HTTPS_PROXY, HTTP_PROXY and
+// NO_PROXY environment variables.
+func (c ExporterConfig) proxy() (func(*http.Request) (*url.URL, error), error) {
+	if c.ProxyURL == "" {
+		// Unlike http.ProxyFromEnvironment, the environment is read for each
+		// exporter rather than once per process.
+		proxyFunc := httpproxy.FromEnvironment().ProxyFunc()
+		return func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}, nil
+	}
+	proxyURL, err := url.Parse(c.ProxyURL)
+	if err != nil {
+		// The error would include the URL and so any password in it.
+		return nil, fmt.Errorf("invalid proxy URL, expected http://[user:password@]host:port")
+	}
+	if (proxyURL.Scheme != "http" && proxyURL.Scheme != "https") || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q, expected http://[user:password@]host:port", proxyURL.Redacted())
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// grpcDialOption dials the gRPC connection of the exporter with its dialer or,
+// without one, through its proxy, if any, by tunnelling it with HTTP CONNECT.
+func (c ExporterConfig) grpcDialOption() (grpc.DialOption, error) {
+	if c.customDialer() {
+		return grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return c.dial(ctx, "tcp", addr)
+		}), nil
+	}
+
+	proxyURL, err := c.grpcProxyURL()
+	if err != nil {
+		return nil, err
+	}
+	target := c.grpcHostPort()
+	return grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		if proxyURL == nil {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", addr)
+		}
+		return dialProxy(ctx, proxyURL, target)
+	}), nil
+}
+
+// grpcProxyURL returns the proxy of the gRPC connection, or nil when it is not
+// proxied. The proxy is resolved for the endpoint host, as gRPC itself would
+// match NO_PROXY against the resolved addresses rather than the host.
+func (c ExporterConfig) grpcProxyURL() (*url.URL, error) {
+	proxy, err := c.proxy()
+	if err != nil {
+		return nil, err
+	}
+	scheme := "https"
+	if c.Insecure {
+		scheme = "http"
+	}
+	proxyURL, err := proxy(&http.Request{URL: &url.URL{Scheme: scheme, Host: c.grpcHostPort()}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve proxy: %w", err)
+	}
+	return proxyURL, nil
+}
+
+// grpcHostPort returns the endpoint of the gRPC connection without its scheme.
+func (c ExporterConfig) grpcHostPort() string {
+	target := c.Endpoint
+	if i := strings.Index(target, "://"); i >= 0 {
+		target = target[i+3:]
+	}
+	return target
+}
+
+// dialProxy opens a tunnel to target through an HTTP proxy, authenticating
+// with the user info of proxyURL, if any.
+func dialProxy(ctx context.Context, proxyURL *url.URL, target string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	if proxyURL.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() { _ = conn.SetDeadline(time.Time{}) }()
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Host: target},
+		Host:   target,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy %s: %w", proxyURL.Redacted(), err)
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read CONNECT response of proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused CONNECT to %s: %s", proxyURL.Redacted(), target, resp.Status)
+	}
+	return &bufferedConn{Conn: conn, r: r}, nil
+}
+
+// bufferedConn reads the bytes the proxy sent after its CONNECT response, which
+// may already be buffered, before reading from the connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
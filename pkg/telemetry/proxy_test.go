@@ -0,0 +1,233 @@
+package telemetry
+
+import (
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testProxy is an HTTP CONNECT proxy that tunnels every connection to the
+// collector, whatever the target, and records the requests it received.
+type testProxy struct {
+	collector     string
+	authorization string
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (p *testProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.requests = append(p.requests, r)
+	p.mu.Unlock()
+
+	if r.Method != http.MethodConnect {
+		http.Error(w, "expected CONNECT", http.StatusMethodNotAllowed)
+		return
+	}
+	if p.authorization != "" && r.Header.Get("Proxy-Authorization") != p.authorization {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+		http.Error(w, "proxy authentication required", http.StatusProxyAuthRequired)
+		return
+	}
+
+	upstream, err := net.Dial("tcp", p.collector)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	conn, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		conn.Close()
+		upstream.Close()
+		return
+	}
+	go func() {
+		_, _ = io.Copy(upstream, buf)
+		upstream.Close()
+	}()
+	go func() {
+		_, _ = io.Copy(conn, upstream)
+		conn.Close()
+	}()
+}
+
+func (p *testProxy) Requests() []*http.Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*http.Request(nil), p.requests...)
+}
+
+// serveProxy serves a testProxy in front of a gRPC testCollector. When
+// authorization is set, the proxy rejects CONNECT requests without it.
+func serveProxy(t *testing.T, authorization string) (*testProxy, *httptest.Server, *testCollector) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := serveCollector(t, listener, ProtocolGRPC)
+	proxy := &testProxy{collector: listener.Addr().String(), authorization: authorization}
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+	return proxy, server, collector
+}
+
+func basicAuth(user, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+}
+
+func TestExportThroughProxyURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *url.Userinfo
+		wantSent bool
+	}{
+		{name: "credentials", user: url.UserPassword("ci", "s3cr3t"), wantSent: true},
+		{name: "wrong password", user: url.UserPassword("ci", "wrong")},
+		{name: "no credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy, server, collector := serveProxy(t, basicAuth("ci", "s3cr3t"))
+			proxyURL, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proxyURL.User = tt.user
+
+			// The endpoint does not resolve, so the span only arrives if it was
+			// tunnelled through the proxy.
+			err = exportTestSpan(t, ExporterConfig{
+				Endpoint: "collector.invalid:4317",
+				Protocol: ProtocolGRPC,
+				Insecure: true,
+				ProxyURL: proxyURL.String(),
+				Timeout:  time.Second,
+				Retry:    &RetryConfig{},
+			})
+			if (err == nil) != tt.wantSent {
+				t.Fatalf("export error = %v, want success %t", err, tt.wantSent)
+			}
+
+			requests := proxy.Requests()
+			if len(requests) == 0 {
+				t.Fatal("proxy received no requests")
+			}
+			if got := requests[0].Host; got != "collector.invalid:4317" {
+				t.Errorf("CONNECT host = %q, want collector.invalid:4317", got)
+			}
+			if tt.user == nil {
+				if got := requests[0].Header.Get("Proxy-Authorization"); got != "" {
+					t.Errorf("Proxy-Authorization = %q, want none", got)
+				}
+			}
+			got := collector.Spans()
+			if tt.wantSent && (len(got) != 1 || got[0] != "build") {
+				t.Errorf("collector received %q, want [build]", got)
+			}
+			if !tt.wantSent && len(got) != 0 {
+				t.Errorf("collector received %q, want nothing", got)
+			}
+		})
+	}
+}
+
+func TestExportThroughEnvironmentProxy(t *testing.T) {
+	// Loopback endpoints never use the environment proxy, so the endpoint is
+	// a documentation address that the proxy redirects to the collector.
+	const endpoint = "192.0.2.1:4317"
+
+	tests := []struct {
+		name      string
+		noProxy   string
+		wantProxy bool
+	}{
+		{name: "proxied", noProxy: "collector.example.com", wantProxy: true},
+		{name: "no proxy host", noProxy: "192.0.2.1"},
+		{name: "no proxy range", noProxy: "example.com,192.0.2.0/24"},
+		{name: "no proxy wildcard", noProxy: "*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy, server, collector := serveProxy(t, basicAuth("ci", "s3cr3t"))
+			proxyURL := strings.Replace(server.URL, "http://", "http://ci:s3cr3t@", 1)
+			t.Setenv("HTTPS_PROXY", proxyURL)
+			t.Setenv("HTTP_PROXY", proxyURL)
+			t.Setenv("NO_PROXY", tt.noProxy)
+
+			err := exportTestSpan(t, ExporterConfig{
+				Endpoint: endpoint,
+				Protocol: ProtocolGRPC,
+				Insecure: true,
+				Timeout:  200 * time.Millisecond,
+				Retry:    &RetryConfig{},
+			})
+
+			requests := proxy.Requests()
+			if !tt.wantProxy {
+				if len(requests) != 0 {
+					t.Errorf("proxy received %d requests, want the endpoint to bypass it", len(requests))
+				}
+				if err == nil {
+					t.Error("export to the unreachable endpoint succeeded, want error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("export error: %v", err)
+			}
+			if len(requests) == 0 || requests[0].Host != endpoint {
+				t.Fatalf("proxy received %d requests, want a CONNECT to %s", len(requests), endpoint)
+			}
+			if got := requests[0].Header.Get("Proxy-Authorization"); got != basicAuth("ci", "s3cr3t") {
+				t.Errorf("Proxy-Authorization = %q, want the credentials of HTTPS_PROXY", got)
+			}
+			if got := collector.Spans(); len(got) != 1 || got[0] != "build" {
+				t.Errorf("collector received %q, want [build]", got)
+			}
+		})
+	}
+}
+
+func TestProxySchemeFromInsecure(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://secure-proxy:3128")
+	t.Setenv("HTTP_PROXY", "http://plain-proxy:3128")
+	t.Setenv("NO_PROXY", "")
+
+	tests := []struct {
+		insecure bool
+		want     string
+	}{
+		{insecure: false, want: "secure-proxy:3128"},
+		{insecure: true, want: "plain-proxy:3128"},
+	}
+	for _, tt := range tests {
+		cfg := ExporterConfig{Endpoint: "collector.example.com:4317", Insecure: tt.insecure}
+		proxy, err := cfg.proxy()
+		if err != nil {
+			t.Fatal(err)
+		}
+		scheme := "https"
+		if tt.insecure {
+			scheme = "http"
+		}
+		got, err := proxy(&http.Request{URL: &url.URL{Scheme: scheme, Host: cfg.Endpoint}})
+		if err != nil || got == nil || got.Host != tt.want {
+			t.Errorf("proxy(insecure %t) = %v, %v, want %s", tt.insecure, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// ReadTracesFile reads the spans of an OTLP JSON file, such as one written by
+// the file exporter.
+func ReadTracesFile(path string) ([]*tracepb.ResourceSpans, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	resourceSpans, err := UnmarshalTracesJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP JSON in %s: %w", path, err)
+	}
+	return resourceSpans, nil
+}
+
+// ReplayTraces exports previously recorded spans as they are, without going
+// through an SDK pipeline, so their ids, timestamps and resources are kept.
+func ReplayTraces(ctx context.Context, cfg ExporterConfig, resourceSpans []*tracepb.ResourceSpans) error {
+	if cfg.Type != ExporterTypeOTLP {
+		return fmt.Errorf("replaying spans requires the %s exporter, got %s", ExporterTypeOTLP, cfg.Type)
+	}
+
+	client, err := NewTraceClient(cfg)
+	if err != nil {
+		return err
+	}
+	if err := client.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start OTLP client: %w", err)
+	}
+
+	// The client retries by itself, unless each retry is to be reported.
+	var retryConfig RetryConfig
+	if cfg.reportsRetries() {
+		retryConfig = *cfg.Retry
+	}
+	uploadErr := retry(ctx, retryConfig, func(ctx context.Context) error {
+		return client.UploadTraces(ctx, resourceSpans)
+	})
+	if err := client.Stop(ctx); err != nil && uploadErr == nil {
+		return fmt.Errorf("failed to stop OTLP client: %w", err)
+	}
+	return uploadErr
+}
+
+// SpanCount returns the number of spans in resourceSpans.
+func SpanCount(resourceSpans []*tracepb.ResourceSpans) int {
+	var count int
+	for _, rs := range resourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			count += len(ss.Spans)
+		}
+	}
+	return count
+}
@@ -0,0 +1,98 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReplayTraces(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []*coltracepb.ExportTraceServiceRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var request coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, &request)
+		mu.Unlock()
+
+		response, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(response)
+	}))
+	defer server.Close()
+
+	want := testResourceSpans(t)
+	data, err := MarshalTracesJSON(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "traces.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resourceSpans, err := ReadTracesFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := SpanCount(resourceSpans); got != 2 {
+		t.Errorf("SpanCount() = %d, want 2", got)
+	}
+
+	cfg := ExporterConfig{Type: ExporterTypeOTLP, Protocol: ProtocolHTTPProtobuf, Endpoint: server.URL}
+	if err := ReplayTraces(context.Background(), cfg, resourceSpans); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("received %d requests, want 1", len(received))
+	}
+	if got := received[0].GetResourceSpans(); len(got) != 1 || !proto.Equal(got[0], want[0]) {
+		t.Errorf("replayed %v, want the spans as recorded: %v", got, want)
+	}
+}
+
+func TestReplayTracesRequiresOTLP(t *testing.T) {
+	cfg := ExporterConfig{Type: ExporterTypeConsole}
+	if err := ReplayTraces(context.Background(), cfg, testResourceSpans(t)); err == nil {
+		t.Error("ReplayTraces() with the console exporter succeeded, want error")
+	}
+}
+
+func TestReadTracesFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(dir, "missing.json"), invalid} {
+		if _, err := ReadTracesFile(path); err == nil {
+			t.Errorf("ReadTracesFile(%q) succeeded, want error", path)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package telemetry
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+// NewResource creates the resource describing the service that emits the CI
+// telemetry. An empty serviceName leaves service.name to attrs.
+func NewResource(serviceName string, attrs []attribute.KeyValue) *resource.Resource {
+	return NewResourceWithSchemaURL(semconv.SchemaURL, serviceName, attrs)
+}
+
+// NewResourceWithSchemaURL is NewResource with the schema URL the attributes
+// follow, e.g. AttributeSchema.SchemaURL.
+func NewResourceWithSchemaURL(schemaURL, serviceName string, attrs []attribute.KeyValue) *resource.Resource {
+	resourceAttributes := make([]attribute.KeyValue, 0, len(attrs)+1)
+	resourceAttributes = append(resourceAttributes, attrs...)
+	if serviceName != "" {
+		resourceAttributes = append(resourceAttributes, attribute.String(string(semconv.ServiceNameKey), serviceName))
+	}
+
+	return resource.NewWithAttributes(schemaURL, resourceAttributes...)
+}
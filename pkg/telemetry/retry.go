@@ -0,0 +1,135 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Defaults of RetryConfig, matching those of the OTLP exporters.
+const (
+	DefaultRetryInitialInterval = 5 * time.Second
+	DefaultRetryMaxInterval     = 30 * time.Second
+	DefaultRetryMaxElapsedTime  = time.Minute
+)
+
+// RetryConfig configures how failed exports are retried with exponential
+// backoff. Zero intervals use the defaults.
+type RetryConfig struct {
+	Enabled         bool
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	MaxElapsedTime  time.Duration
+
+	// OnRetry, if set, is called before each span export retry.
+	OnRetry func(attempt int, delay time.Duration, err error)
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.InitialInterval <= 0 {
+		c.InitialInterval = DefaultRetryInitialInterval
+	}
+	if c.MaxInterval <= 0 {
+		c.MaxInterval = DefaultRetryMaxInterval
+	}
+	if c.MaxElapsedTime <= 0 {
+		c.MaxElapsedTime = DefaultRetryMaxElapsedTime
+	}
+	return c
+}
+
+// retryExporter retries span exports itself, with the retries of the
+// underlying OTLP gRPC client disabled, so that each attempt can be reported.
+type retryExporter struct {
+	sdktrace.SpanExporter
+	cfg RetryConfig
+}
+
+func (e *retryExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	return retry(ctx, e.cfg, func(ctx context.Context) error {
+		return e.SpanExporter.ExportSpans(ctx, spans)
+	})
+}
+
+func retry(ctx context.Context, cfg RetryConfig, fn func(context.Context) error) error {
+	err := fn(ctx)
+	if err == nil || !cfg.Enabled {
+		return err
+	}
+
+	cfg = cfg.withDefaults()
+	deadline := time.Now().Add(cfg.MaxElapsedTime)
+	interval := cfg.InitialInterval
+	for attempt := 1; ; attempt++ {
+		ok, throttle := retryable(err)
+		if !ok {
+			return err
+		}
+		delay := interval
+		if throttle > 0 {
+			delay = throttle
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("giving up after %d attempt(s): %w", attempt, err)
+		}
+		if cfg.OnRetry != nil {
+			cfg.OnRetry(attempt, delay, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		interval = min(interval*2, cfg.MaxInterval)
+	}
+}
+
+// retryable reports whether an export error may be transient, as the OTLP
+// specification defines it, and the delay the server asked for, if any. gRPC
+// errors are classified like the OTLP gRPC exporter does, with the delay of
+// their RetryInfo detail, and transport errors are retried.
+func retryable(err error) (bool, time.Duration) {
+	if errors.Is(err, context.Canceled) {
+		return false, 0
+	}
+	if s, ok := status.FromError(err); ok {
+		throttled, throttle := grpcThrottle(s)
+		switch s.Code() {
+		case codes.Canceled, codes.DeadlineExceeded, codes.Aborted, codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+			return true, throttle
+		case codes.ResourceExhausted:
+			// The server is out of resources for good unless it says when
+			// to retry.
+			return throttled, throttle
+		default:
+			return false, 0
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr), 0
+}
+
+// grpcThrottle returns the delay of the RetryInfo detail of s, and whether it
+// has one.
+func grpcThrottle(s *status.Status) (bool, time.Duration) {
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return true, info.GetRetryDelay().AsDuration()
+		}
+	}
+	return false, 0
+}
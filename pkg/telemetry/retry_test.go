@@ -0,0 +1,260 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestRetry(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "collector restarting")
+	tests := []struct {
+		name       string
+		cfg        RetryConfig
+		errs       []error
+		wantCalls  int
+		wantDelays []time.Duration
+		wantErr    bool
+	}{
+		{
+			name:      "success",
+			cfg:       RetryConfig{Enabled: true},
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name:      "disabled",
+			cfg:       RetryConfig{},
+			errs:      []error{unavailable},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:       "transient errors with backoff",
+			cfg:        RetryConfig{Enabled: true, InitialInterval: time.Millisecond, MaxInterval: 3 * time.Millisecond, MaxElapsedTime: time.Second},
+			errs:       []error{unavailable, unavailable, unavailable, nil},
+			wantCalls:  4,
+			wantDelays: []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond},
+		},
+		{
+			name:      "permanent gRPC error",
+			cfg:       RetryConfig{Enabled: true, InitialInterval: time.Millisecond},
+			errs:      []error{status.Error(codes.PermissionDenied, "denied")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:       "gives up after max elapsed time",
+			cfg:        RetryConfig{Enabled: true, InitialInterval: 20 * time.Millisecond, MaxElapsedTime: 30 * time.Millisecond},
+			errs:       []error{unavailable, unavailable},
+			wantCalls:  2,
+			wantDelays: []time.Duration{20 * time.Millisecond},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delays []time.Duration
+			tt.cfg.OnRetry = func(_ int, delay time.Duration, _ error) { delays = append(delays, delay) }
+
+			calls := 0
+			err := retry(context.Background(), tt.cfg, func(context.Context) error {
+				err := tt.errs[min(calls, len(tt.errs)-1)]
+				calls++
+				return err
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("retry() error = %v, want error %t", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("retry() made %d calls, want %d", calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(delays, tt.wantDelays) {
+				t.Errorf("retry() delays = %v, want %v", delays, tt.wantDelays)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	throttled := func(code codes.Code, delay time.Duration) error {
+		s, err := status.New(code, "slow down").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Err()
+	}
+	tests := []struct {
+		name         string
+		err          error
+		want         bool
+		wantThrottle time.Duration
+	}{
+		{name: "gRPC unavailable", err: status.Error(codes.Unavailable, "unavailable"), want: true},
+		{name: "gRPC unavailable with retry info", err: throttled(codes.Unavailable, 3*time.Second), want: true, wantThrottle: 3 * time.Second},
+		{name: "gRPC resource exhausted", err: status.Error(codes.ResourceExhausted, "exhausted")},
+		{name: "gRPC resource exhausted with retry info", err: throttled(codes.ResourceExhausted, time.Second), want: true, wantThrottle: time.Second},
+		{name: "gRPC permission denied with retry info", err: throttled(codes.PermissionDenied, time.Second)},
+		{name: "wrapped gRPC unavailable", err: fmt.Errorf("traces export: %w", status.Error(codes.Unavailable, "unavailable")), want: true},
+		{name: "cancelled", err: context.Canceled},
+		{name: "other", err: errors.New("boom")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, throttle := retryable(tt.err)
+			if got != tt.want || throttle != tt.wantThrottle {
+				t.Errorf("retryable() = %t, %s, want %t, %s", got, throttle, tt.want, tt.wantThrottle)
+			}
+		})
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg := RetryConfig{Enabled: true, InitialInterval: time.Hour, MaxElapsedTime: 2 * time.Hour, OnRetry: func(int, time.Duration, error) { cancel() }}
+
+	err := retry(ctx, cfg, func(context.Context) error { return status.Error(codes.Unavailable, "unavailable") })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("retry() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestRetryHTTPResponses(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int32
+	}{
+		{name: "bad request", status: http.StatusBadRequest, wantCalls: 1},
+		{name: "unauthorized", status: http.StatusUnauthorized, wantCalls: 1},
+		{name: "forbidden", status: http.StatusForbidden, wantCalls: 1},
+		{name: "payload too large", status: http.StatusRequestEntityTooLarge, wantCalls: 1},
+		{name: "too many requests", status: http.StatusTooManyRequests, wantCalls: 2},
+		{name: "bad gateway", status: http.StatusBadGateway, wantCalls: 2},
+		{name: "service unavailable", status: http.StatusServiceUnavailable, wantCalls: 2},
+		{name: "gateway timeout", status: http.StatusGatewayTimeout, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) > 1 {
+					return
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			// The OTLP/HTTP client retries by itself, without reporting
+			// each retry.
+			exp, err := NewSpanExporter(context.Background(), ExporterConfig{
+				Endpoint: server.URL,
+				Protocol: ProtocolHTTPProtobuf,
+				Insecure: true,
+				Retry: &RetryConfig{
+					Enabled:         true,
+					InitialInterval: time.Millisecond,
+					MaxElapsedTime:  5 * time.Second,
+					OnRetry:         func(int, time.Duration, error) { t.Error("OnRetry called for an OTLP/HTTP export") },
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer exp.Shutdown(context.Background())
+
+			err = exp.ExportSpans(context.Background(), tracetest.SpanStubs{{Name: "build"}}.Snapshots())
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("sent %d requests, want %d (err: %v)", got, tt.wantCalls, err)
+			}
+			if tt.wantCalls == 1 {
+				if err == nil {
+					t.Error("export succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("export error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRetryTransportErrors(t *testing.T) {
+	// A port that was just released refuses connections.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := listener.Addr().String()
+	listener.Close()
+
+	var retries int
+	exp, err := NewSpanExporter(context.Background(), ExporterConfig{
+		Endpoint: endpoint,
+		Protocol: ProtocolGRPC,
+		Insecure: true,
+		Retry: &RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  50 * time.Millisecond,
+			OnRetry:         func(int, time.Duration, error) { retries++ },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exp.Shutdown(context.Background())
+
+	if err := exp.ExportSpans(context.Background(), tracetest.SpanStubs{{Name: "build"}}.Snapshots()); err == nil {
+		t.Fatal("export to a closed server succeeded, want error")
+	}
+	if retries == 0 {
+		t.Error("connection error was not retried")
+	}
+}
+
+func TestNewSpanExporterReportsRetries(t *testing.T) {
+	onRetry := func(int, time.Duration, error) {}
+	tests := []struct {
+		name string
+		cfg  ExporterConfig
+		want bool
+	}{
+		{name: "gRPC reporting retries", cfg: ExporterConfig{Protocol: ProtocolGRPC, Retry: &RetryConfig{Enabled: true, OnRetry: onRetry}}, want: true},
+		{name: "gRPC default policy", cfg: ExporterConfig{Protocol: ProtocolGRPC}},
+		{name: "gRPC without OnRetry", cfg: ExporterConfig{Protocol: ProtocolGRPC, Retry: &RetryConfig{Enabled: true}}},
+		{name: "gRPC retries disabled", cfg: ExporterConfig{Protocol: ProtocolGRPC, Retry: &RetryConfig{OnRetry: onRetry}}},
+		{name: "HTTP", cfg: ExporterConfig{Protocol: ProtocolHTTPProtobuf, Retry: &RetryConfig{Enabled: true, OnRetry: onRetry}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Endpoint = "localhost:4317"
+			cfg.Insecure = true
+			exp, err := NewSpanExporter(context.Background(), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer exp.Shutdown(context.Background())
+
+			if _, got := exp.(*retryExporter); got != tt.want {
+				t.Errorf("NewSpanExporter() wraps retryExporter = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
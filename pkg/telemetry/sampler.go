@@ -0,0 +1,93 @@
+package telemetry
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Sampler names, as in the OTEL_TRACES_SAMPLER environment variable.
+const (
+	SamplerAlwaysOn                = "always_on"
+	SamplerAlwaysOff               = "always_off"
+	SamplerTraceIDRatio            = "traceidratio"
+	SamplerParentBasedAlwaysOn     = "parentbased_always_on"
+	SamplerParentBasedAlwaysOff    = "parentbased_always_off"
+	SamplerParentBasedTraceIDRatio = "parentbased_traceidratio"
+)
+
+// Attributes checked by SampleConclusions.
+var conclusionKeys = []attribute.Key{"ci.github.workflow.job.conclusion", "ci.github.workflow.run.conclusion"}
+
+// ParseSampler creates the sampler named name, with arg as the ratio of the
+// traceidratio samplers. An empty name defaults to parentbased_always_on and
+// an empty arg to 1.
+func ParseSampler(name, arg string) (sdktrace.Sampler, error) {
+	ratio := 1.0
+	if arg = strings.TrimSpace(arg); arg != "" {
+		var err error
+		ratio, err = strconv.ParseFloat(arg, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("invalid sampler argument: %q, expected a ratio between 0 and 1", arg)
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", SamplerParentBasedAlwaysOn:
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case SamplerAlwaysOn:
+		return sdktrace.AlwaysSample(), nil
+	case SamplerAlwaysOff:
+		return sdktrace.NeverSample(), nil
+	case SamplerTraceIDRatio:
+		return sdktrace.TraceIDRatioBased(ratio), nil
+	case SamplerParentBasedAlwaysOff:
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case SamplerParentBasedTraceIDRatio:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	default:
+		return nil, fmt.Errorf("unsupported sampler: %q, expected %s, %s, %s, %s, %s or %s", name,
+			SamplerAlwaysOn, SamplerAlwaysOff, SamplerTraceIDRatio,
+			SamplerParentBasedAlwaysOn, SamplerParentBasedAlwaysOff, SamplerParentBasedTraceIDRatio)
+	}
+}
+
+// SampleConclusions wraps sampler so that spans started with a
+// ci.github.workflow.job.conclusion or ci.github.workflow.run.conclusion
+// attribute whose status is Error are always sampled, e.g. to keep every
+// failed job while sampling the others. The status is decided like the span
+// status, from conclusions and mapping.
+func SampleConclusions(sampler sdktrace.Sampler, conclusions []string, mapping StatusMapping) sdktrace.Sampler {
+	return conclusionSampler{base: sampler, conclusions: conclusions, mapping: mapping}
+}
+
+type conclusionSampler struct {
+	base        sdktrace.Sampler
+	conclusions []string
+	mapping     StatusMapping
+}
+
+func (s conclusionSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, kv := range p.Attributes {
+		if !slices.Contains(conclusionKeys, kv.Key) {
+			continue
+		}
+		if code, _ := s.mapping.Status("", kv.Value.AsString(), s.conclusions); code == codes.Error {
+			return sdktrace.SamplingResult{
+				Decision:   sdktrace.RecordAndSample,
+				Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+			}
+		}
+	}
+	return s.base.ShouldSample(p)
+}
+
+func (s conclusionSampler) Description() string {
+	return fmt.Sprintf("SampleConclusions{%s,%s}", s.base.Description(), strings.Join(s.conclusions, ","))
+}
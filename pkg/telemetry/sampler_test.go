@@ -0,0 +1,87 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParseSampler(t *testing.T) {
+	tests := []struct {
+		name            string
+		arg             string
+		wantDescription string
+		wantErr         bool
+	}{
+		{name: "", wantDescription: "ParentBased{root:AlwaysOnSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}"},
+		{name: "always_on", wantDescription: "AlwaysOnSampler"},
+		{name: " Always_Off ", wantDescription: "AlwaysOffSampler"},
+		{name: "traceidratio", arg: "0.25", wantDescription: "TraceIDRatioBased{0.25}"},
+		{name: "traceidratio", wantDescription: "AlwaysOnSampler"},
+		{name: "parentbased_traceidratio", arg: "0.5", wantDescription: "ParentBased{root:TraceIDRatioBased{0.5},remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}"},
+		{name: "traceidratio", arg: "1.5", wantErr: true},
+		{name: "traceidratio", arg: "half", wantErr: true},
+		{name: "jaeger_remote", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"/"+tt.arg, func(t *testing.T) {
+			sampler, err := ParseSampler(tt.name, tt.arg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSampler(%q, %q) = %s, want error", tt.name, tt.arg, sampler.Description())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSampler(%q, %q) error: %v", tt.name, tt.arg, err)
+			}
+			if got := sampler.Description(); got != tt.wantDescription {
+				t.Errorf("ParseSampler(%q, %q) = %s, want %s", tt.name, tt.arg, got, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestSampleConclusions(t *testing.T) {
+	sampler := SampleConclusions(sdktrace.NeverSample(), []string{"failure", "timed_out"}, StatusMapping{"timed_out": codes.Ok, "cancelled": codes.Error})
+
+	parent, err := ParseTraceContext("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "vendor=value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+
+	tests := []struct {
+		name       string
+		attributes []attribute.KeyValue
+		want       sdktrace.SamplingDecision
+	}{
+		{name: "failed job", attributes: []attribute.KeyValue{attribute.String("ci.github.workflow.job.conclusion", "failure")}, want: sdktrace.RecordAndSample},
+		{name: "failed run", attributes: []attribute.KeyValue{attribute.String("ci.github.workflow.run.conclusion", "FAILURE")}, want: sdktrace.RecordAndSample},
+		{name: "timed out job mapped to ok", attributes: []attribute.KeyValue{attribute.String("ci.github.workflow.job.conclusion", "timed_out")}, want: sdktrace.Drop},
+		{name: "cancelled job mapped to error", attributes: []attribute.KeyValue{attribute.String("ci.github.workflow.job.conclusion", "cancelled")}, want: sdktrace.RecordAndSample},
+		{name: "successful job", attributes: []attribute.KeyValue{attribute.String("ci.github.workflow.job.conclusion", "success")}, want: sdktrace.Drop},
+		{name: "other attribute", attributes: []attribute.KeyValue{attribute.String("result", "failure")}, want: sdktrace.Drop},
+		{name: "no attributes", want: sdktrace.Drop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sampler.ShouldSample(sdktrace.SamplingParameters{
+				ParentContext: ctx,
+				TraceID:       parent.TraceID(),
+				Name:          "build",
+				Attributes:    tt.attributes,
+			})
+			if result.Decision != tt.want {
+				t.Errorf("decision = %v, want %v", result.Decision, tt.want)
+			}
+			if result.Decision == sdktrace.RecordAndSample && result.Tracestate.String() != "vendor=value" {
+				t.Errorf("tracestate = %q, want the parent's vendor=value", result.Tracestate.String())
+			}
+		})
+	}
+}
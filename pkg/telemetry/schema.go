@@ -0,0 +1,104 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+// AttributeSchema selects the attribute names of the emitted spans.
+type AttributeSchema string
+
+const (
+	// AttributeSchemaLegacy emits the ci.github.* attribute names.
+	AttributeSchemaLegacy AttributeSchema = "legacy"
+	// AttributeSchemaCICD emits the cicd.* and vcs.* names of the OpenTelemetry
+	// semantic conventions in place of their ci.github.* equivalents.
+	AttributeSchemaCICD AttributeSchema = "cicd"
+	// AttributeSchemaBoth emits both names, for migrating between them.
+	AttributeSchemaBoth AttributeSchema = "both"
+)
+
+// CICDSchemaURL is the schema URL of the semantic conventions version that
+// defines the CI/CD attributes used by AttributeSchemaCICD.
+const CICDSchemaURL = "https://opentelemetry.io/schemas/1.27.0"
+
+// semconvAttributeNames maps the ci.github.* attribute names to their semantic
+// conventions equivalents.
+var semconvAttributeNames = map[attribute.Key]attribute.Key{
+	"ci.github.workflow.name":            "cicd.pipeline.name",
+	"ci.github.workflow.run.id":          "cicd.pipeline.run.id",
+	"ci.github.workflow.run.head_branch": "vcs.repository.ref.name",
+	"ci.github.workflow.run.head_sha":    "vcs.repository.ref.revision",
+	"ci.github.workflow.job.name":        "cicd.pipeline.task.name",
+	"ci.github.workflow.job.id":          "cicd.pipeline.task.run.id",
+	"ci.github.workflow.job.url":         "cicd.pipeline.task.run.url.full",
+	"ci.github.repository.url":           "vcs.repository.url.full",
+	"ci.github.ref.name":                 "vcs.repository.ref.name",
+	"ci.github.ref.type":                 "vcs.repository.ref.type",
+	"ci.github.sha":                      "vcs.repository.ref.revision",
+	"ci.github.pull_request.number":      "vcs.repository.change.id",
+	"ci.github.pull_request.title":       "vcs.repository.change.title",
+}
+
+// semconvStringAttributes are the semantic conventions attributes that are
+// strings where their ci.github.* equivalent is a number.
+var semconvStringAttributes = map[attribute.Key]bool{
+	"vcs.repository.change.id": true,
+}
+
+// ParseAttributeSchema parses an attribute schema name. An empty value
+// defaults to AttributeSchemaLegacy.
+func ParseAttributeSchema(value string) (AttributeSchema, error) {
+	switch schema := AttributeSchema(strings.ToLower(strings.TrimSpace(value))); schema {
+	case "":
+		return AttributeSchemaLegacy, nil
+	case AttributeSchemaLegacy, AttributeSchemaCICD, AttributeSchemaBoth:
+		return schema, nil
+	default:
+		return "", fmt.Errorf("invalid attribute schema: %q, expected %s, %s or %s", value, AttributeSchemaLegacy, AttributeSchemaCICD, AttributeSchemaBoth)
+	}
+}
+
+// SchemaURL returns the schema URL of the resource for the attribute schema.
+func (s AttributeSchema) SchemaURL() string {
+	if s == AttributeSchemaCICD || s == AttributeSchemaBoth {
+		return CICDSchemaURL
+	}
+	return semconv.SchemaURL
+}
+
+// Apply renames the attributes that have a semantic conventions equivalent,
+// or adds the equivalent next to them for AttributeSchemaBoth. An attribute
+// set more than once, under either name, keeps its last value.
+func (s AttributeSchema) Apply(attrs []attribute.KeyValue) []attribute.KeyValue {
+	applied := make([]attribute.KeyValue, 0, len(attrs))
+	index := make(map[attribute.Key]int, len(attrs))
+	add := func(kv attribute.KeyValue) {
+		if i, ok := index[kv.Key]; ok {
+			applied[i] = kv
+			return
+		}
+		index[kv.Key] = len(applied)
+		applied = append(applied, kv)
+	}
+
+	for _, kv := range attrs {
+		name, ok := semconvAttributeNames[kv.Key]
+		if !ok || (s != AttributeSchemaCICD && s != AttributeSchemaBoth) {
+			add(kv)
+			continue
+		}
+		if s == AttributeSchemaBoth {
+			add(kv)
+		}
+		value := kv.Value
+		if semconvStringAttributes[name] {
+			value = attribute.StringValue(value.Emit())
+		}
+		add(attribute.KeyValue{Key: name, Value: value})
+	}
+	return applied
+}
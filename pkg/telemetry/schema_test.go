@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestAttributeSchemaApply(t *testing.T) {
+	attrs := []attribute.KeyValue{
+		attribute.String("ci.github.workflow.job.name", "build"),
+		attribute.Int64("ci.github.pull_request.number", 7),
+		attribute.String("team", "platform"),
+		attribute.String("ci.github.workflow.job.name", "Build"),
+	}
+
+	tests := []struct {
+		schema AttributeSchema
+		want   []attribute.KeyValue
+	}{
+		{
+			schema: AttributeSchemaLegacy,
+			want: []attribute.KeyValue{
+				attribute.String("ci.github.workflow.job.name", "Build"),
+				attribute.Int64("ci.github.pull_request.number", 7),
+				attribute.String("team", "platform"),
+			},
+		},
+		{
+			schema: AttributeSchemaCICD,
+			want: []attribute.KeyValue{
+				attribute.String("cicd.pipeline.task.name", "Build"),
+				attribute.String("vcs.repository.change.id", "7"),
+				attribute.String("team", "platform"),
+			},
+		},
+		{
+			schema: AttributeSchemaBoth,
+			want: []attribute.KeyValue{
+				attribute.String("ci.github.workflow.job.name", "Build"),
+				attribute.String("cicd.pipeline.task.name", "Build"),
+				attribute.Int64("ci.github.pull_request.number", 7),
+				attribute.String("vcs.repository.change.id", "7"),
+				attribute.String("team", "platform"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.schema), func(t *testing.T) {
+			if got := tt.schema.Apply(attrs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAttributeSchema(t *testing.T) {
+	for value, want := range map[string]AttributeSchema{"": AttributeSchemaLegacy, " CICD ": AttributeSchemaCICD, "both": AttributeSchemaBoth} {
+		if got, err := ParseAttributeSchema(value); err != nil || got != want {
+			t.Errorf("ParseAttributeSchema(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseAttributeSchema("semconv"); err == nil {
+		t.Error(`ParseAttributeSchema("semconv") succeeded, want error`)
+	}
+}
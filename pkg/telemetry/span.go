@@ -0,0 +1,292 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Relationship is how a job span relates to its incoming parent.
+type Relationship string
+
+const (
+	// ChildOf starts the job span as a child of the parent.
+	ChildOf Relationship = "child-of"
+	// FollowsFrom starts the job span as a new root linked to the parent, so
+	// an async-triggered job does not extend the parent's duration.
+	FollowsFrom Relationship = "follows-from"
+)
+
+// ParseRelationship parses a Relationship. An empty value defaults to ChildOf.
+func ParseRelationship(value string) (Relationship, error) {
+	switch Relationship(value) {
+	case "", ChildOf:
+		return ChildOf, nil
+	case FollowsFrom:
+		return FollowsFrom, nil
+	default:
+		return "", fmt.Errorf("invalid parent relationship: %q, expected %s or %s", value, ChildOf, FollowsFrom)
+	}
+}
+
+// JobSpanBuilder builds the span of a CI job.
+type JobSpanBuilder struct {
+	name         string
+	kind         trace.SpanKind
+	start        time.Time
+	parent       trace.SpanContext
+	relationship Relationship
+	rootTraceID  trace.TraceID
+	spanID       trace.SpanID
+	links        []trace.Link
+	events       []SpanEvent
+	attributes   []attribute.KeyValue
+	conclusion   string
+
+	errorConclusions []string
+	statusMapping    StatusMapping
+	statusMessage    string
+	attributeSchema  AttributeSchema
+}
+
+// NewJobSpanBuilder returns a builder for a job span named name that started at
+// start.
+func NewJobSpanBuilder(name string, start time.Time) *JobSpanBuilder {
+	return &JobSpanBuilder{name: name, start: start, relationship: ChildOf}
+}
+
+// WithParent sets the incoming remote parent of the span. Without a parent the
+// span is a child of the span in the start context, or a root if there is none.
+func (b *JobSpanBuilder) WithParent(parent trace.SpanContext, relationship Relationship) *JobSpanBuilder {
+	b.parent = parent
+	b.relationship = relationship
+	return b
+}
+
+// WithRootTraceID sets the trace id used when the span is started as a root.
+func (b *JobSpanBuilder) WithRootTraceID(traceID trace.TraceID) *JobSpanBuilder {
+	b.rootTraceID = traceID
+	return b
+}
+
+// WithSpanID sets the span id instead of generating a random one, e.g. from
+// JobSpanID.
+func (b *JobSpanBuilder) WithSpanID(spanID trace.SpanID) *JobSpanBuilder {
+	b.spanID = spanID
+	return b
+}
+
+// WithLinks adds links to the span.
+func (b *JobSpanBuilder) WithLinks(links ...trace.Link) *JobSpanBuilder {
+	b.links = append(b.links, links...)
+	return b
+}
+
+// WithEvents adds events to the span, e.g. from ParseSpanEvents.
+func (b *JobSpanBuilder) WithEvents(events ...SpanEvent) *JobSpanBuilder {
+	b.events = append(b.events, events...)
+	return b
+}
+
+// WithAttributes adds attributes to the span. Later attributes override earlier
+// ones with the same key.
+func (b *JobSpanBuilder) WithAttributes(attributes ...attribute.KeyValue) *JobSpanBuilder {
+	b.attributes = append(b.attributes, attributes...)
+	return b
+}
+
+// WithKind sets the kind of the span, which defaults to internal.
+func (b *JobSpanBuilder) WithKind(kind trace.SpanKind) *JobSpanBuilder {
+	b.kind = kind
+	return b
+}
+
+// WithMatrix appends the matrix values of the job leg to the span name and
+// sets them as attributes, so that the legs of a matrix can be told apart.
+func (b *JobSpanBuilder) WithMatrix(matrix Matrix) *JobSpanBuilder {
+	if len(matrix) == 0 {
+		return b
+	}
+	b.name = fmt.Sprintf("%s (%s)", b.name, matrix)
+	b.attributes = append(b.attributes, matrix.Attributes()...)
+	return b
+}
+
+// Name returns the name of the span.
+func (b *JobSpanBuilder) Name() string {
+	return b.name
+}
+
+// WithConclusion sets the span status from the job conclusion.
+func (b *JobSpanBuilder) WithConclusion(conclusion string) *JobSpanBuilder {
+	b.conclusion = conclusion
+	return b
+}
+
+// WithErrorConclusions sets the conclusions that map to an error status,
+// instead of DefaultErrorConclusions.
+func (b *JobSpanBuilder) WithErrorConclusions(conclusions []string) *JobSpanBuilder {
+	b.errorConclusions = conclusions
+	return b
+}
+
+// WithStatusMapping overrides the span status of the conclusions in mapping.
+func (b *JobSpanBuilder) WithStatusMapping(mapping StatusMapping) *JobSpanBuilder {
+	b.statusMapping = mapping
+	return b
+}
+
+// WithStatusMessage replaces the description of an error status. Spans only
+// carry a description with an error status.
+func (b *JobSpanBuilder) WithStatusMessage(message string) *JobSpanBuilder {
+	b.statusMessage = message
+	return b
+}
+
+// WithAttributeSchema sets the attribute names of the span. The default is
+// AttributeSchemaLegacy.
+func (b *JobSpanBuilder) WithAttributeSchema(schema AttributeSchema) *JobSpanBuilder {
+	b.attributeSchema = schema
+	return b
+}
+
+// Attributes returns the attributes set on the builder, named according to its
+// attribute schema.
+func (b *JobSpanBuilder) Attributes() []attribute.KeyValue {
+	return b.attributeSchema.Apply(b.attributes)
+}
+
+// Start starts the job span.
+func (b *JobSpanBuilder) Start(ctx context.Context, tracer trace.Tracer) (context.Context, trace.Span) {
+	// The attributes are set at start so that samplers can see them.
+	startOptions := []trace.SpanStartOption{
+		trace.WithTimestamp(b.start),
+		trace.WithLinks(b.links...),
+		trace.WithAttributes(b.Attributes()...),
+	}
+	if b.kind != trace.SpanKindUnspecified {
+		startOptions = append(startOptions, trace.WithSpanKind(b.kind))
+	}
+
+	startCtx := ctx
+	switch {
+	case b.parent.IsValid() && b.relationship == FollowsFrom:
+		startOptions = append(startOptions, trace.WithNewRoot(), trace.WithLinks(trace.Link{
+			SpanContext: b.parent,
+			Attributes:  []attribute.KeyValue{attribute.String("ci.telemetry.link.relationship", string(FollowsFrom))},
+		}))
+	case b.parent.IsValid():
+		ctx = trace.ContextWithRemoteSpanContext(ctx, b.parent)
+		startCtx = ctx
+	case trace.SpanContextFromContext(ctx).IsValid():
+		// The span in ctx is the parent.
+	default:
+		if b.rootTraceID.IsValid() {
+			startCtx = ContextWithTraceID(startCtx, b.rootTraceID)
+		}
+		startOptions = append(startOptions, trace.WithNewRoot())
+	}
+	if b.spanID.IsValid() {
+		startCtx = ContextWithSpanID(startCtx, b.spanID)
+	}
+
+	// The id overrides only apply to this span, so the returned context is
+	// derived from ctx rather than startCtx.
+	_, span := tracer.Start(startCtx, b.name, startOptions...)
+	ctx = trace.ContextWithSpan(ctx, span)
+	for _, event := range b.events {
+		span.AddEvent(event.Name, trace.WithTimestamp(event.Timestamp), trace.WithAttributes(event.Attributes...))
+	}
+	errorConclusions := b.errorConclusions
+	if errorConclusions == nil {
+		errorConclusions = DefaultErrorConclusions
+	}
+	code, description := b.statusMapping.Status("Job", b.conclusion, errorConclusions)
+	if b.statusMessage != "" {
+		description = b.statusMessage
+	}
+	span.SetStatus(code, description)
+	return ctx, span
+}
+
+// JobSpanStatus maps a job conclusion to a span status, with the
+// DefaultErrorConclusions as errors.
+func JobSpanStatus(conclusion string) (codes.Code, string) {
+	return ConclusionStatus("Job", conclusion, DefaultErrorConclusions)
+}
+
+// Step is a step of a CI job.
+type Step struct {
+	Name        string
+	Number      int64
+	Status      string
+	Conclusion  string
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+// StepSpanOptions configures the spans of ExportStepSpans.
+type StepSpanOptions struct {
+	// Attributes are set on every step span, such as baseline attributes. The
+	// attributes of the step override them.
+	Attributes []attribute.KeyValue
+	// AttributeSchema sets the attribute names of the step spans, as
+	// JobSpanBuilder.WithAttributeSchema does for the job span.
+	AttributeSchema AttributeSchema
+}
+
+// ExportStepSpans emits one child span per step of the job in ctx. Steps that
+// have not completed yet, such as the one exporting the telemetry, end at end.
+// It returns the span context of each step, which is invalid for steps that
+// never started.
+func ExportStepSpans(ctx context.Context, tracer trace.Tracer, steps []Step, end time.Time, opts StepSpanOptions) []trace.SpanContext {
+	spanContexts := make([]trace.SpanContext, len(steps))
+	for i, step := range steps {
+		if step.StartedAt == nil {
+			continue
+		}
+
+		stepEnd := end
+		if step.CompletedAt != nil {
+			stepEnd = *step.CompletedAt
+		}
+
+		_, span := tracer.Start(ctx, step.Name, trace.WithTimestamp(*step.StartedAt))
+		span.SetAttributes(opts.AttributeSchema.Apply(append(slices.Clip(opts.Attributes),
+			attribute.String("ci.github.workflow.job.step.name", step.Name),
+			attribute.Int64("ci.github.workflow.job.step.number", step.Number),
+			attribute.String("ci.github.workflow.job.step.status", step.Status),
+			attribute.String("ci.github.workflow.job.step.conclusion", step.Conclusion),
+		))...)
+
+		if step.Conclusion != "" {
+			span.SetStatus(ConclusionStatus("Step", step.Conclusion, DefaultErrorConclusions))
+		}
+
+		span.End(trace.WithTimestamp(stepEnd))
+		spanContexts[i] = span.SpanContext()
+	}
+	return spanContexts
+}
+
+// QueueSpanName is the name of the span covering the time a job was queued.
+const QueueSpanName = "Queued"
+
+// ExportQueueSpan exports a span under the span in ctx covering the time a job
+// waited for a runner, from createdAt to startedAt. Nothing is exported when
+// startedAt is not after createdAt.
+func ExportQueueSpan(ctx context.Context, tracer trace.Tracer, createdAt, startedAt time.Time, attrs ...attribute.KeyValue) {
+	if !startedAt.After(createdAt) {
+		return
+	}
+
+	_, span := tracer.Start(ctx, QueueSpanName, trace.WithTimestamp(createdAt))
+	span.SetAttributes(attribute.Int64("ci.github.workflow.job.queue_duration_ms", startedAt.Sub(createdAt).Milliseconds()))
+	span.SetAttributes(attrs...)
+	span.End(trace.WithTimestamp(startedAt))
+}
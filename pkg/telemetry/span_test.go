@@ -0,0 +1,296 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var jobStart = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+// newRecordingTracer returns a tracer using the IDGenerator of the exporter
+// and the recorder of the spans it ends.
+func newRecordingTracer(t *testing.T) (trace.Tracer, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(recorder),
+		sdktrace.WithIDGenerator(IDGenerator{}),
+	)
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return provider.Tracer(InstrumentationName), recorder
+}
+
+// recordJobSpan starts and ends the span of b and returns it as recorded.
+func recordJobSpan(t *testing.T, b *JobSpanBuilder) sdktrace.ReadOnlySpan {
+	t.Helper()
+	tracer, recorder := newRecordingTracer(t)
+
+	_, span := b.Start(context.Background(), tracer)
+	span.End(trace.WithTimestamp(jobStart.Add(time.Minute)))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	return spans[0]
+}
+
+func attributeMap(attributes []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(attributes))
+	for _, kv := range attributes {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestJobSpanBuilderAttributes(t *testing.T) {
+	span := recordJobSpan(t, NewJobSpanBuilder("build", jobStart).
+		WithAttributes(attribute.String("team", "platform"), attribute.String("tier", "gold")).
+		WithAttributes(attribute.String("team", "payments")))
+
+	if span.Name() != "build" {
+		t.Errorf("name = %q, want build", span.Name())
+	}
+	if !span.StartTime().Equal(jobStart) {
+		t.Errorf("start = %s, want %s", span.StartTime(), jobStart)
+	}
+
+	attributes := attributeMap(span.Attributes())
+	for key, want := range map[attribute.Key]string{"team": "payments", "tier": "gold"} {
+		if got := attributes[key].AsString(); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if len(span.Attributes()) != 2 {
+		t.Errorf("recorded %d attributes, want 2: %v", len(span.Attributes()), span.Attributes())
+	}
+}
+
+func TestJobSpanBuilderStatus(t *testing.T) {
+	tests := []struct {
+		name             string
+		conclusion       string
+		errorConclusions []string
+		mapping          StatusMapping
+		message          string
+		wantCode         codes.Code
+		wantDescription  string
+	}{
+		{name: "success", conclusion: "success", wantCode: codes.Ok},
+		{name: "failure", conclusion: "failure", wantCode: codes.Error, wantDescription: "Job failed"},
+		{name: "timed out", conclusion: "timed_out", wantCode: codes.Error, wantDescription: "Job timed out"},
+		{name: "cancelled", conclusion: "cancelled", wantCode: codes.Ok},
+		{name: "unknown", conclusion: "", wantCode: codes.Unset},
+		{name: "error conclusions", conclusion: "cancelled", errorConclusions: []string{"cancelled"}, wantCode: codes.Error, wantDescription: "Job was cancelled"},
+		{name: "mapped to ok", conclusion: "failure", mapping: StatusMapping{"failure": codes.Ok}, wantCode: codes.Ok},
+		{name: "mapped to error", conclusion: "skipped", mapping: StatusMapping{"skipped": codes.Error}, wantCode: codes.Error, wantDescription: "Job was skipped"},
+		{name: "status message", conclusion: "failure", message: "Test step failed", wantCode: codes.Error, wantDescription: "Test step failed"},
+		{name: "status message without error", conclusion: "success", message: "Test step failed", wantCode: codes.Ok},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span := recordJobSpan(t, NewJobSpanBuilder("build", jobStart).
+				WithConclusion(tt.conclusion).
+				WithErrorConclusions(tt.errorConclusions).
+				WithStatusMapping(tt.mapping).
+				WithStatusMessage(tt.message))
+
+			if got := span.Status(); got.Code != tt.wantCode || got.Description != tt.wantDescription {
+				t.Errorf("status = %s %q, want %s %q", got.Code, got.Description, tt.wantCode, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestJobSpanBuilderParent(t *testing.T) {
+	parent, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTraceID := RunTraceID(42, 1)
+
+	tests := []struct {
+		name         string
+		parent       trace.SpanContext
+		relationship Relationship
+		wantTraceID  trace.TraceID
+		wantParent   trace.SpanID
+		wantLinked   bool
+	}{
+		{name: "root", wantTraceID: rootTraceID},
+		{name: "child of", parent: parent, relationship: ChildOf, wantTraceID: parent.TraceID(), wantParent: parent.SpanID()},
+		{name: "follows from", parent: parent, relationship: FollowsFrom, wantLinked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span := recordJobSpan(t, NewJobSpanBuilder("build", jobStart).
+				WithParent(tt.parent, tt.relationship).
+				WithRootTraceID(rootTraceID))
+
+			if tt.wantTraceID.IsValid() && span.SpanContext().TraceID() != tt.wantTraceID {
+				t.Errorf("trace id = %s, want %s", span.SpanContext().TraceID(), tt.wantTraceID)
+			}
+			if span.Parent().SpanID() != tt.wantParent {
+				t.Errorf("parent span id = %s, want %s", span.Parent().SpanID(), tt.wantParent)
+			}
+
+			links := span.Links()
+			if !tt.wantLinked {
+				if len(links) != 0 {
+					t.Errorf("recorded %d links, want none", len(links))
+				}
+				return
+			}
+			if span.SpanContext().TraceID() == parent.TraceID() {
+				t.Error("follows-from span shares the trace of its parent")
+			}
+			if len(links) != 1 || links[0].SpanContext.SpanID() != parent.SpanID() {
+				t.Fatalf("links = %v, want a link to the parent", links)
+			}
+			if got := attributeMap(links[0].Attributes)["ci.telemetry.link.relationship"].AsString(); got != string(FollowsFrom) {
+				t.Errorf("link relationship = %q, want %q", got, FollowsFrom)
+			}
+		})
+	}
+}
+
+func TestJobSpanBuilderLinks(t *testing.T) {
+	links, err := ParseSpanLinks("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 link.type=deploy,\n00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	span := recordJobSpan(t, NewJobSpanBuilder("build", jobStart).WithLinks(links[0]).WithLinks(links[1]))
+
+	recorded := span.Links()
+	if len(recorded) != 2 {
+		t.Fatalf("recorded %d links, want 2", len(recorded))
+	}
+	for i, link := range recorded {
+		if link.SpanContext.SpanID() != links[i].SpanContext.SpanID() {
+			t.Errorf("link %d span id = %s, want %s", i, link.SpanContext.SpanID(), links[i].SpanContext.SpanID())
+		}
+	}
+	if got := attributeMap(recorded[0].Attributes)["link.type"].AsString(); got != "deploy" {
+		t.Errorf("link.type = %q, want deploy", got)
+	}
+	if recorded[1].SpanContext.IsSampled() {
+		t.Error("the unsampled link is sampled")
+	}
+}
+
+func TestParseRelationship(t *testing.T) {
+	for value, want := range map[string]Relationship{"": ChildOf, "child-of": ChildOf, "follows-from": FollowsFrom} {
+		if got, err := ParseRelationship(value); err != nil || got != want {
+			t.Errorf("ParseRelationship(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseRelationship("sibling-of"); err == nil {
+		t.Error(`ParseRelationship("sibling-of") succeeded, want error`)
+	}
+}
+
+func TestExportStepSpans(t *testing.T) {
+	tracer, recorder := newRecordingTracer(t)
+	ctx, job := NewJobSpanBuilder("build", jobStart).Start(context.Background(), tracer)
+
+	at := func(d time.Duration) *time.Time {
+		ts := jobStart.Add(d)
+		return &ts
+	}
+	end := jobStart.Add(time.Minute)
+	ExportStepSpans(ctx, tracer, []Step{
+		{Name: "Checkout", Number: 1, Status: "completed", Conclusion: "success", StartedAt: at(0), CompletedAt: at(10 * time.Second)},
+		{Name: "Test", Number: 2, Status: "completed", Conclusion: "failure", StartedAt: at(10 * time.Second), CompletedAt: at(50 * time.Second)},
+		{Name: "Export", Number: 3, Status: "in_progress", StartedAt: at(50 * time.Second)},
+		{Name: "Deploy", Number: 4, Status: "queued"},
+	}, end, StepSpanOptions{
+		Attributes: []attribute.KeyValue{
+			attribute.String("team", "platform"),
+			attribute.String("ci.github.workflow.name", "CI"),
+			attribute.String("ci.github.workflow.job.step.name", "baseline"),
+		},
+		AttributeSchema: AttributeSchemaCICD,
+	})
+	job.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("recorded %d spans, want 3 steps and the job", len(spans))
+	}
+	for i, want := range []struct {
+		name string
+		end  time.Time
+		code codes.Code
+	}{
+		{"Checkout", jobStart.Add(10 * time.Second), codes.Ok},
+		{"Test", jobStart.Add(50 * time.Second), codes.Error},
+		{"Export", end, codes.Unset},
+	} {
+		span := spans[i]
+		if span.Name() != want.name || !span.EndTime().Equal(want.end) || span.Status().Code != want.code {
+			t.Errorf("step %d = %q ending %s with %s, want %q ending %s with %s", i, span.Name(), span.EndTime(), span.Status().Code, want.name, want.end, want.code)
+		}
+		if span.Parent().SpanID() != job.SpanContext().SpanID() {
+			t.Errorf("step %q is not a child of the job span", span.Name())
+		}
+		attributes := attributeMap(span.Attributes())
+		if attributes["team"].AsString() != "platform" {
+			t.Errorf("step %q has team %q, want the baseline platform", span.Name(), attributes["team"].AsString())
+		}
+		if got := attributes["ci.github.workflow.job.step.name"].AsString(); got != want.name {
+			t.Errorf("step %q has step name %q, want it to override the baseline", span.Name(), got)
+		}
+		if _, ok := attributes["ci.github.workflow.name"]; ok || attributes["cicd.pipeline.name"].AsString() != "CI" {
+			t.Errorf("step %q attributes = %v, want the names of the cicd schema", span.Name(), span.Attributes())
+		}
+	}
+}
+
+func TestJobSpanBuilderEvents(t *testing.T) {
+	events, err := ParseSpanEvents("tests started|2024-05-01T10:00:10Z|suite=unit,shards=4\ntests finished|1714557650")
+	if err != nil {
+		t.Fatal(err)
+	}
+	span := recordJobSpan(t, NewJobSpanBuilder("build", jobStart).WithEvents(events...))
+
+	recorded := span.Events()
+	if len(recorded) != 2 {
+		t.Fatalf("recorded %d events, want 2", len(recorded))
+	}
+	for i, want := range []struct {
+		name string
+		time time.Time
+	}{
+		{"tests started", jobStart.Add(10 * time.Second)},
+		{"tests finished", jobStart.Add(50 * time.Second)},
+	} {
+		if recorded[i].Name != want.name || !recorded[i].Time.Equal(want.time) {
+			t.Errorf("event %d = %q at %s, want %q at %s", i, recorded[i].Name, recorded[i].Time, want.name, want.time)
+		}
+	}
+	attributes := attributeMap(recorded[0].Attributes)
+	if attributes["suite"].AsString() != "unit" || attributes["shards"].AsInt64() != 4 {
+		t.Errorf("event attributes = %v, want suite=unit and shards=4", recorded[0].Attributes)
+	}
+}
+
+func TestParseSpanEventsErrors(t *testing.T) {
+	for _, input := range []string{
+		"no timestamp",
+		"|2024-05-01T10:00:00Z",
+		"deploy|yesterday",
+		"deploy|2024-05-01T10:00:00Z|bad",
+		"deploy|2024-05-01T10:00:00Z|n:int=x",
+	} {
+		if _, err := ParseSpanEvents(input); err == nil {
+			t.Errorf("ParseSpanEvents(%q) succeeded, want error", input)
+		}
+	}
+}
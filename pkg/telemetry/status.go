@@ -0,0 +1,50 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// StatusMapping overrides the span status of conclusions, e.g. to treat the
+// failure of a job that is allowed to fail as OK.
+type StatusMapping map[string]codes.Code
+
+// ParseStatusMapping parses conclusion=status pairs, where the status is ok,
+// error or unset.
+func ParseStatusMapping(pairs map[string]string) (StatusMapping, error) {
+	mapping := make(StatusMapping, len(pairs))
+	for conclusion, status := range pairs {
+		var code codes.Code
+		switch strings.ToLower(strings.TrimSpace(status)) {
+		case "ok":
+			code = codes.Ok
+		case "error":
+			code = codes.Error
+		case "unset":
+			code = codes.Unset
+		default:
+			return nil, fmt.Errorf("invalid status %q for conclusion %q, expected ok, error or unset", status, conclusion)
+		}
+		mapping[strings.ToLower(strings.TrimSpace(conclusion))] = code
+	}
+	return mapping, nil
+}
+
+// Status maps the conclusion of subject to a span status like
+// ConclusionStatus, unless the mapping overrides the conclusion.
+func (m StatusMapping) Status(subject, conclusion string, errorConclusions []string) (codes.Code, string) {
+	code, description := ConclusionStatus(subject, conclusion, errorConclusions)
+	if mapped, ok := m[strings.ToLower(strings.TrimSpace(conclusion))]; ok {
+		code = mapped
+	}
+	return code, description
+}
+
+// ExpandStatusMessage replaces the {placeholder}s in template with values, like
+// ExpandSpanName.
+func ExpandStatusMessage(template string, values map[string]string) (string, error) {
+	message, err := expandTemplate("status message", template, values)
+	return strings.TrimSpace(message), err
+}
@@ -0,0 +1,64 @@
+package telemetry
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestParseStatusMapping(t *testing.T) {
+	mapping, err := ParseStatusMapping(map[string]string{"failure": "ok", " Cancelled ": "ERROR", "skipped": "unset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := StatusMapping{"failure": codes.Ok, "cancelled": codes.Error, "skipped": codes.Unset}
+	if len(mapping) != len(want) {
+		t.Fatalf("ParseStatusMapping() = %v, want %v", mapping, want)
+	}
+	for conclusion, code := range want {
+		if got, ok := mapping[conclusion]; !ok || got != code {
+			t.Errorf("mapping[%q] = %s, want %s", conclusion, got, code)
+		}
+	}
+
+	if _, err := ParseStatusMapping(map[string]string{"failure": "fine"}); err == nil {
+		t.Error("ParseStatusMapping() with an invalid status succeeded, want error")
+	}
+}
+
+func TestStatusMappingStatus(t *testing.T) {
+	mapping := StatusMapping{"failure": codes.Ok, "cancelled": codes.Error}
+	tests := []struct {
+		conclusion      string
+		wantCode        codes.Code
+		wantDescription string
+	}{
+		{conclusion: "failure", wantCode: codes.Ok, wantDescription: "Job failed"},
+		{conclusion: "Cancelled", wantCode: codes.Error, wantDescription: "Job was cancelled"},
+		{conclusion: "timed_out", wantCode: codes.Error, wantDescription: "Job timed out"},
+		{conclusion: "success", wantCode: codes.Ok, wantDescription: "Job completed successfully"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.conclusion, func(t *testing.T) {
+			code, description := mapping.Status("Job", tt.conclusion, DefaultErrorConclusions)
+			if code != tt.wantCode || description != tt.wantDescription {
+				t.Errorf("Status(%q) = %s %q, want %s %q", tt.conclusion, code, description, tt.wantCode, tt.wantDescription)
+			}
+		})
+	}
+
+	var empty StatusMapping
+	if code, _ := empty.Status("Job", "failure", DefaultErrorConclusions); code != codes.Error {
+		t.Errorf("nil mapping Status(failure) = %s, want %s", code, codes.Error)
+	}
+}
+
+func TestExpandStatusMessage(t *testing.T) {
+	got, err := ExpandStatusMessage("{job} failed at {failed_step} ", map[string]string{"job": "build", "failed_step": "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "build failed at Test"; got != want {
+		t.Errorf("ExpandStatusMessage() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,9 @@
+// Package telemetry builds and exports OpenTelemetry traces and metrics for CI
+// jobs. It is used by the export-job-telemetry GitHub Action and can be
+// imported by other Go tooling to emit the same telemetry without shelling out
+// to the action binary.
+package telemetry
+
+// InstrumentationName is the name of the tracer and meter used for CI
+// telemetry.
+const InstrumentationName = "export-job-telemetry"
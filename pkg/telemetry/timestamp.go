@@ -0,0 +1,41 @@
+package telemetry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampLayouts are the formats accepted by ParseTimestamp besides epoch
+// times: RFC 3339, with or without a colon in the offset, and the format of
+// older GitHub API responses.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006/01/02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -0700",
+}
+
+// epochMillisThreshold separates epoch seconds from epoch milliseconds: as
+// seconds it lies in the year 5138, as milliseconds in 1973.
+const epochMillisThreshold = 1e11
+
+// ParseTimestamp parses an RFC 3339 timestamp, epoch seconds or milliseconds,
+// or a timestamp in the format of older GitHub API responses.
+func ParseTimestamp(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if epoch >= epochMillisThreshold || epoch <= -epochMillisThreshold {
+			return time.UnixMilli(epoch).UTC(), nil
+		}
+		return time.Unix(epoch, 0).UTC(), nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q, expected RFC 3339 or epoch seconds or milliseconds", value)
+}
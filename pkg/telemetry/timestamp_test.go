@@ -0,0 +1,47 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "RFC 3339", value: "2024-05-01T10:00:00Z", want: want},
+		{name: "RFC 3339 with offset", value: "2024-05-01T12:00:00+02:00", want: want},
+		{name: "RFC 3339 with fraction", value: "2024-05-01T10:00:00.250Z", want: want.Add(250 * time.Millisecond)},
+		{name: "offset without colon", value: "2024-05-01T12:00:00+0200", want: want},
+		{name: "fraction and offset without colon", value: "2024-05-01T12:00:00.5+0200", want: want.Add(500 * time.Millisecond)},
+		{name: "older GitHub API format", value: "2024/05/01 10:00:00 +0000", want: want},
+		{name: "space separated", value: "2024-05-01 10:00:00 +0000", want: want},
+		{name: "epoch seconds", value: "1714557600", want: want},
+		{name: "epoch milliseconds", value: "1714557600250", want: want.Add(250 * time.Millisecond)},
+		{name: "surrounding whitespace", value: " 1714557600\n", want: want},
+		{name: "empty", value: "", wantErr: true},
+		{name: "date only", value: "2024-05-01", wantErr: true},
+		{name: "text", value: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseTimestamp(%q) = %s, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTimestamp(%q) error: %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTimestamp(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
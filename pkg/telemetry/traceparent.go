@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	traceparentVersion = "00"
+	traceparentLength  = 55
+)
+
+// ParseTraceparent parses a W3C traceparent into a remote span context.
+func ParseTraceparent(traceparent string) (trace.SpanContext, error) {
+	return ParseTraceContext(traceparent, "")
+}
+
+// ParseTraceContext parses a W3C traceparent and an optional tracestate into a
+// remote span context, following the Trace Context specification: version ff
+// and all-zero ids are rejected, the trace flags are kept as sent, and future
+// versions are parsed as version 00, ignoring any trailing fields.
+func ParseTraceContext(traceparent, tracestate string) (trace.SpanContext, error) {
+	traceparent = strings.TrimSpace(traceparent)
+	if len(traceparent) < traceparentLength {
+		return trace.SpanContext{}, fmt.Errorf("invalid traceparent: %q", traceparent)
+	}
+
+	version := traceparent[:2]
+	if !isLowerHex(version) || version == "ff" {
+		return trace.SpanContext{}, fmt.Errorf("invalid traceparent version: %q", version)
+	}
+	if version == traceparentVersion && len(traceparent) != traceparentLength {
+		return trace.SpanContext{}, fmt.Errorf("invalid traceparent: %q", traceparent)
+	}
+	if len(traceparent) > traceparentLength && traceparent[traceparentLength] != '-' {
+		return trace.SpanContext{}, fmt.Errorf("invalid traceparent: %q", traceparent)
+	}
+
+	parts := strings.Split(traceparent[:traceparentLength], "-")
+	if len(parts) != 4 {
+		return trace.SpanContext{}, fmt.Errorf("invalid traceparent: %q", traceparent)
+	}
+
+	traceID, err := trace.TraceIDFromHex(parts[1])
+	if err != nil || !isLowerHex(parts[1]) {
+		return trace.SpanContext{}, fmt.Errorf("invalid TraceID: %v", parts[1])
+	}
+
+	spanID, err := trace.SpanIDFromHex(parts[2])
+	if err != nil || !isLowerHex(parts[2]) {
+		return trace.SpanContext{}, fmt.Errorf("invalid SpanID: %v", parts[2])
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 || !isLowerHex(parts[3]) {
+		return trace.SpanContext{}, fmt.Errorf("invalid trace flags: %v", parts[3])
+	}
+
+	traceState, err := trace.ParseTraceState(strings.TrimSpace(tracestate))
+	if err != nil {
+		return trace.SpanContext{}, fmt.Errorf("invalid tracestate: %w", err)
+	}
+
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.TraceFlags(flags[0]),
+		TraceState: traceState,
+		Remote:     true,
+	}), nil
+}
+
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatTraceparent formats a span context as a W3C traceparent.
+func FormatTraceparent(spanContext trace.SpanContext) string {
+	return fmt.Sprintf("00-%s-%s-%s", spanContext.TraceID(), spanContext.SpanID(), spanContext.TraceFlags())
+}
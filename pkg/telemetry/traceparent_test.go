@@ -0,0 +1,76 @@
+package telemetry
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParseTraceContext(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	tests := []struct {
+		name        string
+		traceparent string
+		tracestate  string
+		wantFlags   trace.TraceFlags
+		wantState   string
+		wantErr     bool
+	}{
+		{name: "sampled", traceparent: "00-" + traceID + "-" + spanID + "-01", wantFlags: trace.FlagsSampled},
+		{name: "unsampled", traceparent: "00-" + traceID + "-" + spanID + "-00"},
+		{name: "surrounding whitespace", traceparent: " 00-" + traceID + "-" + spanID + "-01\n", wantFlags: trace.FlagsSampled},
+		{name: "tracestate", traceparent: "00-" + traceID + "-" + spanID + "-01", tracestate: "vendor1=value1,vendor2=value2", wantFlags: trace.FlagsSampled, wantState: "vendor1=value1,vendor2=value2"},
+		{name: "future version", traceparent: "01-" + traceID + "-" + spanID + "-01", wantFlags: trace.FlagsSampled},
+		{name: "future version with trailing fields", traceparent: "01-" + traceID + "-" + spanID + "-01-extra", wantFlags: trace.FlagsSampled},
+		{name: "empty", traceparent: "", wantErr: true},
+		{name: "too short", traceparent: "00-" + traceID + "-" + spanID, wantErr: true},
+		{name: "version 00 with trailing fields", traceparent: "00-" + traceID + "-" + spanID + "-01-extra", wantErr: true},
+		{name: "version ff", traceparent: "ff-" + traceID + "-" + spanID + "-01", wantErr: true},
+		{name: "uppercase hex", traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + spanID + "-01", wantErr: true},
+		{name: "zero trace id", traceparent: "00-00000000000000000000000000000000-" + spanID + "-01", wantErr: true},
+		{name: "zero span id", traceparent: "00-" + traceID + "-0000000000000000-01", wantErr: true},
+		{name: "invalid flags", traceparent: "00-" + traceID + "-" + spanID + "-0g", wantErr: true},
+		{name: "wrong separator", traceparent: "00_" + traceID + "_" + spanID + "_01", wantErr: true},
+		{name: "invalid tracestate", traceparent: "00-" + traceID + "-" + spanID + "-01", tracestate: "novalue", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTraceContext(tt.traceparent, tt.tracestate)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseTraceContext(%q, %q) = %v, want error", tt.traceparent, tt.tracestate, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTraceContext(%q, %q) error: %v", tt.traceparent, tt.tracestate, err)
+			}
+			if got.TraceID().String() != traceID || got.SpanID().String() != spanID {
+				t.Errorf("ids = %s %s, want %s %s", got.TraceID(), got.SpanID(), traceID, spanID)
+			}
+			if got.TraceFlags() != tt.wantFlags {
+				t.Errorf("flags = %s, want %s", got.TraceFlags(), tt.wantFlags)
+			}
+			if got.TraceState().String() != tt.wantState {
+				t.Errorf("tracestate = %q, want %q", got.TraceState().String(), tt.wantState)
+			}
+			if !got.IsRemote() {
+				t.Error("span context is not remote")
+			}
+		})
+	}
+}
+
+func TestFormatTraceparent(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	spanContext, err := ParseTraceparent(traceparent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatTraceparent(spanContext); got != traceparent {
+		t.Errorf("FormatTraceparent() = %q, want %q", got, traceparent)
+	}
+}
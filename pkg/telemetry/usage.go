@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ResourceUsage is the resource usage of a runner. Values that are not
+// available are zero.
+//
+// The load averages and the current memory and disk use are a snapshot taken
+// when the job is exported, though the load averages cover the last 1, 5 and
+// 15 minutes, so they still reflect how busy the runner was while the job ran.
+// The peak memory and the CPU utilization come from the runner's cgroup and
+// cover the job.
+type ResourceUsage struct {
+	CPUCount int
+
+	Load1  float64
+	Load5  float64
+	Load15 float64
+
+	// CPUUtilization is the average share of the CPUs used by the runner's
+	// cgroup since the CPUSample taken at the start of the job, from 0 to 1.
+	CPUUtilization float64
+
+	MemoryTotalBytes   int64
+	MemoryCurrentBytes int64
+	// MemoryPeakBytes is the peak memory use of the runner's cgroup.
+	MemoryPeakBytes int64
+
+	DiskTotalBytes int64
+	DiskUsedBytes  int64
+}
+
+// CPUSample is the CPU time used by the runner's cgroup up to Time.
+type CPUSample struct {
+	Time  time.Time
+	Usage time.Duration
+}
+
+// IsZero reports whether s is the zero CPUSample.
+func (s CPUSample) IsZero() bool {
+	return s.Time.IsZero()
+}
+
+// cpuUtilization returns the average share of cpus used between the start and
+// end samples, or 0 when it cannot be measured.
+func cpuUtilization(start, end CPUSample, cpus int) float64 {
+	elapsed := end.Time.Sub(start.Time)
+	if start.IsZero() || elapsed <= 0 || cpus <= 0 || end.Usage < start.Usage {
+		return 0
+	}
+	return min(1, float64(end.Usage-start.Usage)/float64(elapsed)/float64(cpus))
+}
+
+// Attributes returns the usage as ci.github.workflow.job.runner.* attributes,
+// omitting values that are not available.
+func (u ResourceUsage) Attributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if u.CPUCount > 0 {
+		attrs = append(attrs,
+			attribute.Int("ci.github.workflow.job.runner.cpu.count", u.CPUCount),
+			attribute.Float64("ci.github.workflow.job.runner.cpu.load_1m", u.Load1),
+			attribute.Float64("ci.github.workflow.job.runner.cpu.load_5m", u.Load5),
+			attribute.Float64("ci.github.workflow.job.runner.cpu.load_15m", u.Load15),
+		)
+	}
+	if u.CPUUtilization > 0 {
+		attrs = append(attrs, attribute.Float64("ci.github.workflow.job.runner.cpu.utilization", u.CPUUtilization))
+	}
+	for _, value := range []struct {
+		key   string
+		bytes int64
+	}{
+		{"ci.github.workflow.job.runner.memory.total_bytes", u.MemoryTotalBytes},
+		{"ci.github.workflow.job.runner.memory.current_bytes", u.MemoryCurrentBytes},
+		{"ci.github.workflow.job.runner.memory.peak_bytes", u.MemoryPeakBytes},
+		{"ci.github.workflow.job.runner.disk.total_bytes", u.DiskTotalBytes},
+		{"ci.github.workflow.job.runner.disk.used_bytes", u.DiskUsedBytes},
+	} {
+		if value.bytes > 0 {
+			attrs = append(attrs, attribute.Int64(value.key, value.bytes))
+		}
+	}
+	return attrs
+}
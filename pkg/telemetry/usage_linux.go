@@ -0,0 +1,175 @@
+//go:build linux
+
+package telemetry
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// hostFS is the filesystem /proc and the cgroup filesystem are read from. Its
+// paths have no leading slash, such as proc/loadavg.
+var hostFS fs.FS = os.DirFS("/")
+
+// ReadResourceUsage reads the resource usage of the runner from /proc and the
+// cgroup filesystem, and the disk usage of the filesystem holding dir. The CPU
+// utilization is measured since start, unless it is zero.
+func ReadResourceUsage(dir string, start CPUSample) (ResourceUsage, error) {
+	usage, err := readResourceUsage(hostFS, runtime.NumCPU(), start, time.Now())
+	if err != nil {
+		return usage, err
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return usage, fmt.Errorf("failed to stat filesystem of %s: %w", dir, err)
+	}
+	usage.DiskTotalBytes = int64(stat.Blocks) * int64(stat.Bsize)
+	usage.DiskUsedBytes = int64(stat.Blocks-stat.Bfree) * int64(stat.Bsize)
+	return usage, nil
+}
+
+// readResourceUsage reads the CPU and memory usage of a runner with cpus CPUs
+// from fsys at now.
+func readResourceUsage(fsys fs.FS, cpus int, start CPUSample, now time.Time) (ResourceUsage, error) {
+	usage := ResourceUsage{CPUCount: cpus}
+
+	loadavg, err := fs.ReadFile(fsys, "proc/loadavg")
+	if err != nil {
+		return usage, err
+	}
+	if _, err := fmt.Sscanf(string(loadavg), "%f %f %f", &usage.Load1, &usage.Load5, &usage.Load15); err != nil {
+		return usage, fmt.Errorf("invalid /proc/loadavg: %w", err)
+	}
+	if !start.IsZero() {
+		if end, err := readCPUSample(fsys, now); err == nil {
+			usage.CPUUtilization = cpuUtilization(start, end, usage.CPUCount)
+		}
+	}
+
+	meminfo, err := readMeminfo(fsys)
+	if err != nil {
+		return usage, err
+	}
+	usage.MemoryTotalBytes = meminfo["MemTotal"]
+	if available, ok := meminfo["MemAvailable"]; ok {
+		usage.MemoryCurrentBytes = usage.MemoryTotalBytes - available
+	}
+	usage.MemoryPeakBytes = cgroupMemoryPeak(fsys)
+	return usage, nil
+}
+
+// readMeminfo returns the values of /proc/meminfo in bytes.
+func readMeminfo(fsys fs.FS) (map[string]int64, error) {
+	f, err := fsys.Open("proc/meminfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]int64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			n *= 1024
+		}
+		values[key] = n
+	}
+	return values, scanner.Err()
+}
+
+// ReadCPUSample reads the CPU time used so far by the cgroup of the process,
+// from cpu.stat with cgroup v2 or cpuacct.usage with cgroup v1.
+func ReadCPUSample() (CPUSample, error) {
+	return readCPUSample(hostFS, time.Now())
+}
+
+func readCPUSample(fsys fs.FS, now time.Time) (CPUSample, error) {
+	if name, ok := cgroupV2File(fsys, "cpu.stat"); ok {
+		if usec, ok := readKeyedInt(fsys, name, "usage_usec"); ok {
+			return CPUSample{Time: now, Usage: time.Duration(usec) * time.Microsecond}, nil
+		}
+	}
+	for _, name := range []string{"sys/fs/cgroup/cpuacct/cpuacct.usage", "sys/fs/cgroup/cpu,cpuacct/cpuacct.usage"} {
+		if ns, ok := readInt(fsys, name); ok {
+			return CPUSample{Time: now, Usage: time.Duration(ns)}, nil
+		}
+	}
+	return CPUSample{}, errors.New("no cgroup CPU accounting found")
+}
+
+// cgroupMemoryPeak returns the peak memory use of the cgroup of the process,
+// from memory.peak with cgroup v2 or memory.max_usage_in_bytes with cgroup v1,
+// or 0 when neither is available.
+func cgroupMemoryPeak(fsys fs.FS) int64 {
+	if name, ok := cgroupV2File(fsys, "memory.peak"); ok {
+		if peak, ok := readInt(fsys, name); ok {
+			return peak
+		}
+	}
+	if peak, ok := readInt(fsys, "sys/fs/cgroup/memory/memory.max_usage_in_bytes"); ok {
+		return peak
+	}
+	return 0
+}
+
+// cgroupV2File returns the path of a file of the cgroup v2 of the process.
+func cgroupV2File(fsys fs.FS, name string) (string, bool) {
+	cgroup, err := fs.ReadFile(fsys, "proc/self/cgroup")
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(cgroup), "\n") {
+		if cgroupPath, ok := strings.CutPrefix(line, "0::"); ok {
+			return path.Join("sys/fs/cgroup", cgroupPath, name), true
+		}
+	}
+	return "", false
+}
+
+// readInt reads a file holding a single integer.
+func readInt(fsys fs.FS, name string) (int64, bool) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	return n, err == nil
+}
+
+// readKeyedInt reads the integer of key from a file of "key value" lines, such
+// as cpu.stat.
+func readKeyedInt(fsys fs.FS, name, key string) (int64, bool) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if value, ok := strings.CutPrefix(line, key+" "); ok {
+			n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,162 @@
+//go:build linux
+
+package telemetry
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const (
+	testLoadavg = "1.50 0.75 0.25 2/345 6789\n"
+	testMeminfo = "MemTotal:       16384000 kB\n" +
+		"MemFree:         2048000 kB\n" +
+		"MemAvailable:    4096000 kB\n" +
+		"HugePages_Total:       0\n"
+)
+
+func TestReadResourceUsage(t *testing.T) {
+	start := CPUSample{Time: jobStart, Usage: 10 * time.Second}
+	now := jobStart.Add(10 * time.Second)
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  ResourceUsage
+	}{
+		{
+			name: "cgroup v2",
+			files: fstest.MapFS{
+				"proc/self/cgroup": {Data: []byte("0::/system.slice/runner.service\n")},
+				"sys/fs/cgroup/system.slice/runner.service/cpu.stat":    {Data: []byte("usage_usec 25000000\nuser_usec 20000000\nsystem_usec 5000000\n")},
+				"sys/fs/cgroup/system.slice/runner.service/memory.peak": {Data: []byte("3221225472\n")},
+			},
+			// 15s of CPU time over 10s on 2 CPUs.
+			want: ResourceUsage{CPUUtilization: 0.75, MemoryPeakBytes: 3221225472},
+		},
+		{
+			name: "cgroup v2 root",
+			files: fstest.MapFS{
+				"proc/self/cgroup":          {Data: []byte("0::/\n")},
+				"sys/fs/cgroup/cpu.stat":    {Data: []byte("usage_usec 15000000\n")},
+				"sys/fs/cgroup/memory.peak": {Data: []byte("1073741824\n")},
+			},
+			want: ResourceUsage{CPUUtilization: 0.25, MemoryPeakBytes: 1073741824},
+		},
+		{
+			name: "cgroup v1",
+			files: fstest.MapFS{
+				"proc/self/cgroup":                               {Data: []byte("12:memory:/docker/abc\n11:cpu,cpuacct:/docker/abc\n")},
+				"sys/fs/cgroup/cpuacct/cpuacct.usage":            {Data: []byte("20000000000\n")},
+				"sys/fs/cgroup/memory/memory.max_usage_in_bytes": {Data: []byte("2147483648\n")},
+			},
+			want: ResourceUsage{CPUUtilization: 0.5, MemoryPeakBytes: 2147483648},
+		},
+		{
+			name: "cgroup v1 combined controllers",
+			files: fstest.MapFS{
+				"proc/self/cgroup":                        {Data: []byte("11:cpu,cpuacct:/\n")},
+				"sys/fs/cgroup/cpu,cpuacct/cpuacct.usage": {Data: []byte("20000000000\n")},
+			},
+			want: ResourceUsage{CPUUtilization: 0.5},
+		},
+		{
+			name: "utilization capped at 1",
+			files: fstest.MapFS{
+				"proc/self/cgroup":       {Data: []byte("0::/\n")},
+				"sys/fs/cgroup/cpu.stat": {Data: []byte("usage_usec 60000000\n")},
+			},
+			want: ResourceUsage{CPUUtilization: 1},
+		},
+		{
+			name: "no cgroup accounting",
+			files: fstest.MapFS{
+				"proc/self/cgroup": {Data: []byte("0::/\n")},
+			},
+		},
+		{
+			name: "cgroup v2 file without usage",
+			files: fstest.MapFS{
+				"proc/self/cgroup":          {Data: []byte("0::/\n")},
+				"sys/fs/cgroup/cpu.stat":    {Data: []byte("user_usec 1\n")},
+				"sys/fs/cgroup/memory.peak": {Data: []byte("max\n")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.files["proc/loadavg"] = &fstest.MapFile{Data: []byte(testLoadavg)}
+			tt.files["proc/meminfo"] = &fstest.MapFile{Data: []byte(testMeminfo)}
+
+			got, err := readResourceUsage(tt.files, 2, start, now)
+			if err != nil {
+				t.Fatalf("readResourceUsage() error: %v", err)
+			}
+			want := tt.want
+			want.CPUCount = 2
+			want.Load1, want.Load5, want.Load15 = 1.5, 0.75, 0.25
+			want.MemoryTotalBytes = 16384000 * 1024
+			want.MemoryCurrentBytes = (16384000 - 4096000) * 1024
+			if got != want {
+				t.Errorf("readResourceUsage() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestReadResourceUsageWithoutStart(t *testing.T) {
+	files := fstest.MapFS{
+		"proc/loadavg":           {Data: []byte(testLoadavg)},
+		"proc/meminfo":           {Data: []byte("MemTotal: 1024 kB\n")},
+		"proc/self/cgroup":       {Data: []byte("0::/\n")},
+		"sys/fs/cgroup/cpu.stat": {Data: []byte("usage_usec 25000000\n")},
+	}
+	got, err := readResourceUsage(files, 2, CPUSample{}, jobStart)
+	if err != nil {
+		t.Fatalf("readResourceUsage() error: %v", err)
+	}
+	if got.CPUUtilization != 0 {
+		t.Errorf("CPUUtilization = %v, want 0 without a start sample", got.CPUUtilization)
+	}
+	// Without MemAvailable the current use is unknown.
+	if got.MemoryTotalBytes != 1024*1024 || got.MemoryCurrentBytes != 0 {
+		t.Errorf("memory = %d total, %d current, want %d, 0", got.MemoryTotalBytes, got.MemoryCurrentBytes, 1024*1024)
+	}
+}
+
+func TestReadResourceUsageErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{name: "no loadavg", files: fstest.MapFS{"proc/meminfo": {Data: []byte(testMeminfo)}}},
+		{name: "invalid loadavg", files: fstest.MapFS{"proc/loadavg": {Data: []byte("high\n")}, "proc/meminfo": {Data: []byte(testMeminfo)}}},
+		{name: "no meminfo", files: fstest.MapFS{"proc/loadavg": {Data: []byte(testLoadavg)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readResourceUsage(tt.files, 2, CPUSample{}, jobStart); err == nil {
+				t.Error("readResourceUsage() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestReadCPUSample(t *testing.T) {
+	files := fstest.MapFS{
+		"proc/self/cgroup":                        {Data: []byte("0::/actions_job/1234\n")},
+		"sys/fs/cgroup/actions_job/1234/cpu.stat": {Data: []byte("usage_usec 1500\n")},
+	}
+	got, err := readCPUSample(files, jobStart)
+	if err != nil {
+		t.Fatalf("readCPUSample() error: %v", err)
+	}
+	if want := (CPUSample{Time: jobStart, Usage: 1500 * time.Microsecond}); got != want {
+		t.Errorf("readCPUSample() = %+v, want %+v", got, want)
+	}
+
+	if _, err := readCPUSample(fstest.MapFS{}, jobStart); err == nil {
+		t.Error("readCPUSample() without cgroup accounting succeeded, want error")
+	}
+}
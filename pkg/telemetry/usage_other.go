@@ -0,0 +1,18 @@
+//go:build !linux
+
+package telemetry
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ReadResourceUsage is only supported on Linux runners.
+func ReadResourceUsage(string, CPUSample) (ResourceUsage, error) {
+	return ResourceUsage{}, fmt.Errorf("resource usage is not supported on %s", runtime.GOOS)
+}
+
+// ReadCPUSample is only supported on Linux runners.
+func ReadCPUSample() (CPUSample, error) {
+	return CPUSample{}, fmt.Errorf("resource usage is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,38 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCPUUtilization(t *testing.T) {
+	start := CPUSample{Time: jobStart, Usage: time.Minute}
+	end := func(elapsed, used time.Duration) CPUSample {
+		return CPUSample{Time: jobStart.Add(elapsed), Usage: time.Minute + used}
+	}
+
+	tests := []struct {
+		name  string
+		start CPUSample
+		end   CPUSample
+		cpus  int
+		want  float64
+	}{
+		{name: "one CPU", start: start, end: end(10*time.Second, 5*time.Second), cpus: 1, want: 0.5},
+		{name: "shared across CPUs", start: start, end: end(10*time.Second, 10*time.Second), cpus: 4, want: 0.25},
+		{name: "idle", start: start, end: end(10*time.Second, 0), cpus: 2, want: 0},
+		{name: "capped at 1", start: start, end: end(10*time.Second, 30*time.Second), cpus: 2, want: 1},
+		{name: "no start sample", end: end(10*time.Second, 5*time.Second), cpus: 1, want: 0},
+		{name: "no elapsed time", start: start, end: end(0, 5*time.Second), cpus: 1, want: 0},
+		{name: "end before start", start: start, end: end(-time.Second, 5*time.Second), cpus: 1, want: 0},
+		{name: "usage went down", start: start, end: end(10*time.Second, -time.Second), cpus: 1, want: 0},
+		{name: "no CPUs", start: start, end: end(10*time.Second, 5*time.Second), cpus: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cpuUtilization(tt.start, tt.end, tt.cpus); got != tt.want {
+				t.Errorf("cpuUtilization() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}